@@ -21,75 +21,212 @@ import (
 	"html/template"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 
-	"gopkg.in/yaml.v2"
-
-	dittoJson "github.com/99nil/ditto/json"
-
 	"github.com/zc2638/apidoc/resource"
 	"github.com/zc2638/apidoc/swag"
 )
 
-var defaultTemplate = template.New("default").
-	Funcs(template.FuncMap{
-		"toLower":         strings.ToLower,
-		"toHTML":          toHTML,
-		"mdToHTML":        mdToHTML,
-		"checkTag":        checkTag,
-		"getEndpointSet":  getEndpointSet,
-		"getParameters":   getParameters,
-		"getBody":         getBody,
-		"getResponse":     getResponse,
-		"getBodyRows":     getBodyRows,
-		"getResponseRows": getResponseRows,
-	})
+// DefaultTemplate is the name of the built-in template used by Parse.
+const DefaultTemplate = "default"
 
-func Parse(content []byte) ([]byte, error) {
-	var obj swag.API
+var funcMap = template.FuncMap{
+	"toLower":             strings.ToLower,
+	"join":                strings.Join,
+	"toHTML":              toHTML,
+	"mdToHTML":            mdToHTML,
+	"checkTag":            checkTag,
+	"getEndpointSet":      getEndpointSet,
+	"getTagGroups":        getTagGroups,
+	"getParameters":       getParameters,
+	"getBody":             getBody,
+	"getResponse":         getResponse,
+	"getBodyExamples":     getBodyExamples,
+	"getResponseExamples": getResponseExamples,
+	"getBodyRows":         getBodyRows,
+	"getResponseRows":     getResponseRows,
+}
 
-	line, pos, ok := dittoJson.CheckBytes(content)
-	if !ok {
-		jsonErrStr := fmt.Sprintf("\njson check failed, line: %d, pos: %d", line, pos)
-		if err := yaml.Unmarshal(content, &obj); err != nil {
-			return nil, fmt.Errorf("%s\nyaml parse failed: %v", jsonErrStr, err)
-		}
-	} else {
-		if err := json.Unmarshal(content, &obj); err != nil {
-			return nil, fmt.Errorf("json parse failed: %v", err)
-		}
-	}
-	obj.TransformSchemas()
+// Parse parses the swagger 2.0 or openapi 3.x content and renders it with the default template.
+func Parse(content []byte) ([]byte, error) {
+	return ParseWithTemplate(content, DefaultTemplate)
+}
 
-	tpl, err := resource.ReadTemplate("default")
+// ParseWithTemplate parses the swagger 2.0 or openapi 3.x content and renders it with the named template.
+// See LoadTemplate for the supported template names.
+func ParseWithTemplate(content []byte, name string) ([]byte, error) {
+	obj, err := Load(content)
 	if err != nil {
 		return nil, err
 	}
-	t, err := defaultTemplate.Parse(string(tpl))
+	return Render(obj, name)
+}
+
+// Render renders the API with the named template.
+// See LoadTemplate for the supported template names, and RenderFormat for the other output formats.
+func Render(api *swag.API, name string) ([]byte, error) {
+	t, err := LoadTemplate(name)
 	if err != nil {
 		return nil, err
 	}
 
 	var buf bytes.Buffer
-	if err := t.Execute(&buf, &obj); err != nil {
+	if err := t.Execute(&buf, api); err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
 }
 
+// LoadTemplate loads the template with all the template functions.
+// The name can be the name of a built-in template (default, table), a path of a template file,
+// or a directory of `.html` templates whose entry is `index.html`.
+// The built-in names take precedence, a local file or directory with the same name is loaded by a path like `./default`.
+func LoadTemplate(name string) (*template.Template, error) {
+	if IsBuiltinTemplate(name) {
+		tpl, err := resource.ReadTemplate(name)
+		if err != nil {
+			return nil, err
+		}
+		return template.New(name).Funcs(funcMap).Parse(string(tpl))
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, fmt.Errorf("load template %s failed: %v", name, err)
+	}
+	if !info.IsDir() {
+		return template.New(filepath.Base(name)).Funcs(funcMap).ParseFiles(name)
+	}
+	t, err := template.New(filepath.Base(name)).Funcs(funcMap).ParseGlob(filepath.Join(name, "*.html"))
+	if err != nil {
+		return nil, fmt.Errorf("load template dir %s failed: %v", name, err)
+	}
+	index := t.Lookup(indexTemplate)
+	if index == nil {
+		return nil, fmt.Errorf("load template dir %s failed: %s not found", name, indexTemplate)
+	}
+	return index, nil
+}
+
+const indexTemplate = "index.html"
+
+// IsBuiltinTemplate reports whether the name refers to a built-in template,
+// the names with a path separator or an extension are always paths.
+func IsBuiltinTemplate(name string) bool {
+	if strings.ContainsAny(name, `/\`) || filepath.Ext(name) != "" {
+		return false
+	}
+	return resource.HasTemplate(name)
+}
+
+// Load decodes the json or yaml content into an API.
+// OpenAPI 3.x definitions are detected by the `openapi` field and converted.
+func Load(content []byte) (*swag.API, error) {
+	return load(content, maxAliasNodes)
+}
+
+// load is Load expanding at most aliasNodes nodes from the yaml aliases.
+func load(content []byte, aliasNodes int) (*swag.API, error) {
+	doc, err := decodeDocumentAliases(content, aliasNodes)
+	if err != nil {
+		return nil, err
+	}
+
+	root, _ := doc.(map[string]interface{})
+	// `openapi: 3.1` is decoded as a number from yaml
+	version, _ := stringValue(root["openapi"])
+
+	var obj *swag.API
+	if strings.HasPrefix(version, "3.") {
+		var spec swag.OpenAPI
+		if err := decodeTyped(doc, &spec); err != nil {
+			return nil, err
+		}
+		if obj, err = spec.ToAPI(); err != nil {
+			return nil, err
+		}
+	} else {
+		obj = new(swag.API)
+		if err := decodeTyped(doc, obj); err != nil {
+			return nil, err
+		}
+	}
+	if err := obj.TransformSchemas(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// decodeTyped decodes the generic document into the value pointed by v,
+// the scalars of its string fields are coerced first.
+func decodeTyped(doc interface{}, v interface{}) error {
+	data, err := json.Marshal(coerceScalars(doc, reflect.TypeOf(v)))
+	if err != nil {
+		return fmt.Errorf("json convert failed: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("json parse failed: %v", err)
+	}
+	return nil
+}
+
 func ParseFromURL(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+	content, err := ReadURL(url)
 	if err != nil {
 		return nil, err
 	}
+	return Parse(content)
+}
+
+// ReadURL returns the content responded by the url.
+func ReadURL(url string) ([]byte, error) {
+	return readURL(http.DefaultClient, url, 0)
+}
+
+// readURL returns the content responded by the url with the client,
+// the content larger than limit bytes is rejected unless limit is 0.
+func readURL(client *http.Client, url string, limit int64) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("request failed, expect %d, actual %d", http.StatusOK, resp.StatusCode)
 	}
-	defer resp.Body.Close()
 
+	var reader io.Reader = resp.Body
+	if limit > 0 {
+		reader = io.LimitReader(resp.Body, limit+1)
+	}
 	var body bytes.Buffer
-	if _, err := io.Copy(&body, resp.Body); err != nil {
+	if _, err := io.Copy(&body, reader); err != nil {
 		return nil, err
 	}
-	return Parse(body.Bytes())
+	if limit > 0 && int64(body.Len()) > limit {
+		return nil, fmt.Errorf("content exceeds the max size of %d bytes", limit)
+	}
+	return body.Bytes(), nil
+}
+
+// ReadSource returns the content of the src, which can be a http(s) url or a file path.
+func ReadSource(src string) ([]byte, error) {
+	if IsURL(src) {
+		return ReadURL(src)
+	}
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return nil, fmt.Errorf("read src file failed: %v", err)
+	}
+	return content, nil
+}
+
+// IsURL reports whether the src is a url with a host.
+func IsURL(src string) bool {
+	uri, err := url.Parse(src)
+	return err == nil && uri.Host != ""
 }
@@ -0,0 +1,126 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+// writeTemplates writes the template files into a temporary directory and returns it.
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestIsBuiltinTemplate(t *testing.T) {
+	for name, want := range map[string]bool{
+		"default":      true,
+		"table":        true,
+		"missing":      false,
+		"./default":    false,
+		"default.html": false,
+		"docs/table":   false,
+		`docs\table`:   false,
+	} {
+		if got := IsBuiltinTemplate(name); got != want {
+			t.Errorf("IsBuiltinTemplate(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"custom.html":       `{{ toLower .Info.Title }} {{ join .Schemes "," }}`,
+		"site/index.html":   `{{ template "header.html" . }}<p>{{ .Info.Version }}</p>`,
+		"site/header.html":  `<h1>{{ .Info.Title }}</h1>`,
+		"partial/main.html": `{{ .Info.Title }}`,
+	})
+	api := &swag.API{Info: swag.Info{Title: "Pets", Version: "1.0"}, Schemes: []string{"http", "https"}}
+	render := func(name string) (string, error) {
+		tpl, err := LoadTemplate(name)
+		if err != nil {
+			return "", err
+		}
+		var buf strings.Builder
+		err = tpl.Execute(&buf, api)
+		return buf.String(), err
+	}
+
+	for name, want := range map[string]string{
+		// the template functions are available to a template file
+		filepath.Join(dir, "custom.html"): "pets http,https",
+		// the entry of a directory is index.html, which can use the other templates of the directory
+		filepath.Join(dir, "site"): "<h1>Pets</h1><p>1.0</p>",
+	} {
+		got, err := render(name)
+		if err != nil || got != want {
+			t.Errorf("template %s renders %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	partial := filepath.Join(dir, "partial")
+	_, err := render(partial)
+	if want := "load template dir " + partial + " failed: index.html not found"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %s", err, want)
+	}
+	if _, err := render(filepath.Join(dir, "missing.html")); err == nil {
+		t.Error("the missing template is loaded without an error")
+	}
+}
+
+func TestLoadTemplateBuiltinFirst(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{"default/index.html": "local"})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	api := &swag.API{Info: swag.Info{Title: "pets"}}
+	// the built-in name takes precedence over the local directory, which is loaded by a path
+	builtin, err := Render(api, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(builtin), "<html") {
+		t.Errorf("default renders %q, want the built-in template", builtin)
+	}
+	local, err := Render(api, "./default")
+	if err != nil || string(local) != "local" {
+		t.Errorf("./default renders %q, %v, want the local template", local, err)
+	}
+}
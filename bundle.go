@@ -0,0 +1,471 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+// LoadOption configures the resolution of the references to other documents.
+type LoadOption struct {
+	// RemoteRefs allows the http(s) references,
+	// including the relative references of a src which is an url.
+	RemoteRefs bool
+}
+
+// LoadSource reads the src, which is a file path or an url, and decodes it into an API.
+// The references to other documents are bundled by Bundle first.
+func LoadSource(src string, opt LoadOption) (*swag.API, error) {
+	content, err := ReadSource(src)
+	if err != nil {
+		return nil, err
+	}
+	content, err = Bundle(content, src, opt)
+	if err != nil {
+		return nil, err
+	}
+	return Load(content)
+}
+
+// Bundle resolves the references to other documents in the json or yaml content read from the src,
+// such as `./models/user.yaml#/User` or `common.json#/definitions/Error`,
+// the relative references are resolved against the src and then against the document containing them.
+//
+// The referred schemas are added to the definitions (or the component schemas of OpenAPI 3.x)
+// named after the last token of their pointers or their file names, and the references are rewritten to them,
+// so that the recursive schemas across the documents are kept as references.
+// The other referred objects, such as parameters and responses, are inlined.
+// The content is returned unchanged if it has no references to other documents.
+func Bundle(content []byte, src string, opt LoadOption) ([]byte, error) {
+	data, _, err := bundle(content, src, opt)
+	return data, err
+}
+
+// Sources returns the locations of the src and the documents it refers to, directly or indirectly,
+// local files are returned as absolute paths.
+// The locations found before an error are returned with it, e.g. a referred document failed to decode.
+func Sources(src string, opt LoadOption) ([]string, error) {
+	content, err := ReadSource(src)
+	if err != nil {
+		return []string{src}, err
+	}
+	_, sources, err := bundle(content, src, opt)
+	if len(sources) == 0 {
+		sources = []string{src}
+	}
+	return sources, err
+}
+
+// bundle bundles the content and returns the locations of the documents read, the src first.
+func bundle(content []byte, src string, opt LoadOption) ([]byte, []string, error) {
+	location := src
+	if !IsURL(src) {
+		var err error
+		if location, err = filepath.Abs(src); err != nil {
+			return nil, nil, fmt.Errorf("resolve src path failed: %v", err)
+		}
+	}
+	doc, err := decodeDocument(content)
+	if err != nil {
+		return nil, []string{location}, err
+	}
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return content, []string{location}, nil
+	}
+
+	b := newBundler(root, location, opt)
+	if _, err := b.visit(root, location, "", bundleObject); err != nil {
+		return nil, b.sources, err
+	}
+	if !b.changed {
+		return content, b.sources, nil
+	}
+
+	if len(b.definitions) > 0 {
+		if b.existing == nil {
+			b.existing = make(map[string]interface{})
+			if b.prefix == "#/definitions/" {
+				root["definitions"] = b.existing
+			} else {
+				components, _ := root["components"].(map[string]interface{})
+				if components == nil {
+					components = make(map[string]interface{})
+					root["components"] = components
+				}
+				components["schemas"] = b.existing
+			}
+		}
+		for name, schema := range b.definitions {
+			b.existing[name] = schema
+		}
+	}
+	data, err := json.Marshal(root)
+	if err != nil {
+		return nil, b.sources, fmt.Errorf("bundle marshal failed: %v", err)
+	}
+	return data, b.sources, nil
+}
+
+// unresolvedRefs returns the references to other documents in the json or yaml content read from the src
+// which fail to bundle, in the order of the document. An error is returned only if the content can not be decoded.
+func unresolvedRefs(content []byte, src string, opt LoadOption) ([]unresolvedRef, error) {
+	location := src
+	if !IsURL(src) {
+		var err error
+		if location, err = filepath.Abs(src); err != nil {
+			return nil, fmt.Errorf("resolve src path failed: %v", err)
+		}
+	}
+	doc, err := decodeDocument(content)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	b := newBundler(root, location, opt)
+	b.collect = true
+	if _, err := b.visit(root, location, "", bundleObject); err != nil {
+		return nil, err
+	}
+	return b.unresolved, nil
+}
+
+func newBundler(root map[string]interface{}, location string, opt LoadOption) *bundler {
+	b := &bundler{
+		opt:         opt,
+		root:        location,
+		documents:   map[string]interface{}{location: root},
+		sources:     []string{location},
+		names:       make(map[string]string),
+		inlining:    make(map[string]struct{}),
+		definitions: make(map[string]interface{}),
+	}
+	b.prefix, b.existing = bundleDefinitions(root)
+	return b
+}
+
+// bundleDefinitions returns the ref prefix and the definitions of the root document.
+func bundleDefinitions(root map[string]interface{}) (string, map[string]interface{}) {
+	if _, ok := root["openapi"]; ok {
+		components, _ := root["components"].(map[string]interface{})
+		schemas, _ := components["schemas"].(map[string]interface{})
+		return "#/components/schemas/", schemas
+	}
+	definitions, _ := root["definitions"].(map[string]interface{})
+	return "#/definitions/", definitions
+}
+
+// bundleKind is the kind of a value, which decides how the references in it are bundled.
+type bundleKind int
+
+const (
+	bundleObject     bundleKind = iota // any value other than a schema.
+	bundleSchema                       // a schema.
+	bundleSchemaMap                    // a map of schemas, such as properties.
+	bundleSchemaList                   // a list of schemas, such as allOf.
+)
+
+var (
+	// schemaKeys are the schema keywords whose values are schemas.
+	schemaKeys = map[string]bundleKind{
+		"items":                bundleSchema,
+		"additionalProperties": bundleSchema,
+		"additionalItems":      bundleSchema,
+		"not":                  bundleSchema,
+		"contains":             bundleSchema,
+		"propertyNames":        bundleSchema,
+		"if":                   bundleSchema,
+		"then":                 bundleSchema,
+		"else":                 bundleSchema,
+		"properties":           bundleSchemaMap,
+		"patternProperties":    bundleSchemaMap,
+		"dependentSchemas":     bundleSchemaMap,
+		"definitions":          bundleSchemaMap,
+		"$defs":                bundleSchemaMap,
+		"allOf":                bundleSchemaList,
+		"anyOf":                bundleSchemaList,
+		"oneOf":                bundleSchemaList,
+		"prefixItems":          bundleSchemaList,
+	}
+
+	// objectKeys are the keys of the other objects whose values are schemas.
+	objectKeys = map[string]bundleKind{
+		"schema":      bundleSchema,
+		"items":       bundleSchema,
+		"definitions": bundleSchemaMap,
+		"schemas":     bundleSchemaMap,
+	}
+)
+
+type bundler struct {
+	opt       LoadOption
+	root      string                 // location of the root document.
+	documents map[string]interface{} // decoded documents by location.
+	sources   []string               // locations of the documents read, including the failed ones.
+
+	prefix      string                 // ref prefix of the root definitions.
+	existing    map[string]interface{} // definitions of the root document.
+	definitions map[string]interface{} // bundled schemas by name.
+	names       map[string]string      // names of the bundled schemas by reference.
+	inlining    map[string]struct{}    // references being inlined, to detect cycles.
+	changed     bool
+
+	// collect keeps the references of the root document failed to bundle in unresolved instead of failing.
+	collect    bool
+	unresolved []unresolvedRef
+}
+
+// unresolvedRef is a reference of the root document failed to bundle.
+type unresolvedRef struct {
+	pointer string // json pointer of the $ref in the root document.
+	err     error
+}
+
+// visit bundles the references in the value at the pointer of the document at the location,
+// the value with the references replaced is returned.
+func (b *bundler) visit(v interface{}, location, pointer string, kind bundleKind) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := val["$ref"].(string); ok && (kind == bundleSchema || kind == bundleObject) {
+			if location == b.root && strings.HasPrefix(ref, "#") {
+				return val, nil
+			}
+			bundled, err := b.ref(ref, location, kind)
+			if err != nil && b.collect && location == b.root {
+				b.unresolved = append(b.unresolved, unresolvedRef{pointer: pointer + "/$ref", err: err})
+				return val, nil
+			}
+			return bundled, err
+		}
+		// visited in order, so that the names of the colliding definitions are stable
+		for _, k := range sortedKeys(val) {
+			item := val[k]
+			child := bundleObject
+			switch kind {
+			case bundleSchema:
+				child = schemaKeys[k]
+			case bundleObject:
+				child = objectKeys[k]
+			case bundleSchemaMap:
+				child = bundleSchema
+			}
+			item, err := b.visit(item, location, pointer+swag.JSONPointer(k), child)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = item
+		}
+	case []interface{}:
+		child := bundleObject
+		if kind == bundleSchemaList || kind == bundleSchema {
+			// a list of schemas, or the tuple items of a schema
+			child = bundleSchema
+		}
+		for i, item := range val {
+			item, err := b.visit(item, location, pointer+"/"+strconv.Itoa(i), child)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = item
+		}
+	}
+	return v, nil
+}
+
+// ref bundles the reference found in the document at the location.
+func (b *bundler) ref(ref, location string, kind bundleKind) (interface{}, error) {
+	file, fragment := ref, ""
+	if i := strings.Index(ref, "#"); i > -1 {
+		file, fragment = ref[:i], ref[i+1:]
+	}
+	target, err := b.resolve(location, file)
+	if err != nil {
+		return nil, fmt.Errorf("resolve $ref %s failed: %v", ref, err)
+	}
+	if target == b.root {
+		return map[string]interface{}{"$ref": "#" + fragment}, nil
+	}
+	b.changed = true
+
+	key := target + "#" + fragment
+	if strings.HasPrefix(fragment, "/definitions/") || strings.HasPrefix(fragment, "/components/schemas/") {
+		kind = bundleSchema
+	}
+	if kind == bundleSchema {
+		if name, ok := b.names[key]; ok {
+			return map[string]interface{}{"$ref": b.prefix + name}, nil
+		}
+		value, err := b.lookup(target, fragment)
+		if err != nil {
+			return nil, fmt.Errorf("resolve $ref %s failed: %v", ref, err)
+		}
+		name := b.name(target, fragment)
+		// registered before visiting the schema, so that it can refer to itself
+		b.names[key] = name
+		b.definitions[name] = nil
+		schema, err := b.visit(copyValue(value), target, fragment, bundleSchema)
+		if err != nil {
+			return nil, err
+		}
+		b.definitions[name] = schema
+		return map[string]interface{}{"$ref": b.prefix + name}, nil
+	}
+
+	if _, ok := b.inlining[key]; ok {
+		return nil, fmt.Errorf("circular $ref: %s", ref)
+	}
+	b.inlining[key] = struct{}{}
+	defer delete(b.inlining, key)
+
+	value, err := b.lookup(target, fragment)
+	if err != nil {
+		return nil, fmt.Errorf("resolve $ref %s failed: %v", ref, err)
+	}
+	return b.visit(copyValue(value), target, fragment, kind)
+}
+
+// resolve returns the location of the file referred in the document at the location.
+func (b *bundler) resolve(location, file string) (string, error) {
+	if file == "" {
+		return location, nil
+	}
+	var target string
+	switch {
+	case IsURL(file):
+		target = file
+	case IsURL(location):
+		base, err := url.Parse(location)
+		if err != nil {
+			return "", err
+		}
+		ref, err := url.Parse(file)
+		if err != nil {
+			return "", err
+		}
+		target = base.ResolveReference(ref).String()
+	case filepath.IsAbs(file):
+		target = filepath.Clean(file)
+	default:
+		target = filepath.Join(filepath.Dir(location), filepath.FromSlash(file))
+	}
+	if IsURL(target) && !b.opt.RemoteRefs {
+		return "", fmt.Errorf("remote reference %s is not allowed", target)
+	}
+	return target, nil
+}
+
+// lookup returns the value pointed by the fragment in the document at the location.
+func (b *bundler) lookup(location, fragment string) (interface{}, error) {
+	doc, ok := b.documents[location]
+	if !ok {
+		b.sources = append(b.sources, location)
+		content, err := ReadSource(location)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = decodeDocument(content); err != nil {
+			return nil, fmt.Errorf("decode %s failed: %v", location, err)
+		}
+		b.documents[location] = doc
+	}
+
+	fragment = strings.TrimPrefix(fragment, "/")
+	if fragment == "" {
+		return doc, nil
+	}
+	v := doc
+	for _, token := range strings.Split(fragment, "/") {
+		token = unescapePointer(token)
+		switch val := v.(type) {
+		case map[string]interface{}:
+			v, ok = val[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			ok = err == nil && i >= 0 && i < len(val)
+			if ok {
+				v = val[i]
+			}
+		default:
+			ok = false
+		}
+		if !ok {
+			return nil, fmt.Errorf("%s not found in %s", "#/"+fragment, location)
+		}
+	}
+	return v, nil
+}
+
+// name returns an unused definition name for the schema,
+// which is the last token of the fragment or the file name.
+func (b *bundler) name(location, fragment string) string {
+	name := ""
+	if tokens := strings.Split(strings.Trim(fragment, "/"), "/"); tokens[len(tokens)-1] != "" {
+		name = unescapePointer(tokens[len(tokens)-1])
+	} else {
+		file := filepath.ToSlash(location)
+		if u, err := url.Parse(location); err == nil && IsURL(location) {
+			file = u.Path
+		}
+		name = path.Base(file)
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	name = strings.ReplaceAll(name, "/", ".")
+
+	used := func(name string) bool {
+		_, defined := b.existing[name]
+		_, bundled := b.definitions[name]
+		return defined || bundled
+	}
+	if !used(name) {
+		return name
+	}
+	for i := 2; ; i++ {
+		if candidate := name + strconv.Itoa(i); !used(candidate) {
+			return candidate
+		}
+	}
+}
+
+// copyValue deeply copies the decoded value,
+// so that a value referred several times is bundled separately each time.
+func copyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = copyValue(item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(val))
+		for i, item := range val {
+			s[i] = copyValue(item)
+		}
+		return s
+	}
+	return v
+}
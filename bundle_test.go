@@ -0,0 +1,260 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// bundleFile bundles the fixture in testdata/bundle and decodes the result.
+func bundleFile(t *testing.T, src string, opt LoadOption) (map[string]interface{}, error) {
+	t.Helper()
+	src = filepath.Join("testdata", "bundle", filepath.FromSlash(src))
+	content, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decodeBundled(t, content, src, opt)
+}
+
+func decodeBundled(t *testing.T, content []byte, src string, opt LoadOption) (map[string]interface{}, error) {
+	t.Helper()
+	data, err := Bundle(content, src, opt)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeDocument(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.(map[string]interface{}), nil
+}
+
+// valueAt returns the value at the json pointer of the decoded document.
+func valueAt(doc interface{}, pointer string) (interface{}, bool) {
+	v := doc
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = val[unescapePointer(token)]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(val) {
+				return nil, false
+			}
+			v = val[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func TestBundle(t *testing.T) {
+	cases := []struct {
+		name        string
+		src         string
+		definitions []string
+		values      map[string]interface{}
+	}{
+		{
+			// tree.yaml refers to itself directly and through leaf.yaml, it is hoisted once and kept as references
+			name:        "file cycles",
+			src:         "cycle/swagger.yaml",
+			definitions: []string{"Forest", "Leaf", "tree"},
+			values: map[string]interface{}{
+				"/paths/~1trees/get/responses/200/schema/$ref":     "#/definitions/tree",
+				"/definitions/Forest/items/$ref":                   "#/definitions/tree",
+				"/definitions/tree/properties/children/items/$ref": "#/definitions/tree",
+				"/definitions/tree/properties/leaf/$ref":           "#/definitions/Leaf",
+				"/definitions/Leaf/properties/tree/$ref":           "#/definitions/tree",
+			},
+		},
+		{
+			// the Pet of each file gets its own name, the one of pets/pet.yaml is hoisted once
+			name:        "name collisions",
+			src:         "collision/swagger.yaml",
+			definitions: []string{"Again", "Pet", "Pet2", "Pet3", "Pets", "Users"},
+			values: map[string]interface{}{
+				"/definitions/Pet/type":                   "string",
+				"/definitions/Again/$ref":                 "#/definitions/Pet2",
+				"/definitions/Pets/$ref":                  "#/definitions/Pet2",
+				"/definitions/Users/$ref":                 "#/definitions/Pet3",
+				"/definitions/Pet2/properties/name/type":  "string",
+				"/definitions/Pet3/properties/owner/type": "string",
+			},
+		},
+		{
+			// the refs inside models/pet.yaml are relative to it, the `#/...` refs point into their own file
+			name:        "relative refs",
+			src:         "relative/swagger.yaml",
+			definitions: []string{"Owner", "Pet", "Pet2", "Tag"},
+			values: map[string]interface{}{
+				"/paths/~1pets/get/parameters/0/name":         "limit",
+				"/paths/~1pets/get/parameters/0/in":           "query",
+				"/paths/~1pets/get/responses/200/schema/$ref": "#/definitions/Pet",
+				"/definitions/Pet/properties/owner/$ref":      "#/definitions/Owner",
+				"/definitions/Pet/properties/tag/$ref":        "#/definitions/Tag",
+				"/definitions/Owner/properties/pet/$ref":      "#/definitions/Pet2",
+				"/definitions/Pet2/$ref":                      "#/definitions/Pet",
+				"/definitions/Tag/type":                       "string",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc, err := bundleFile(t, c.src, LoadOption{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			definitions, _ := doc["definitions"].(map[string]interface{})
+			var names []string
+			for name := range definitions {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, c.definitions) {
+				t.Errorf("definitions = %v, want %v", names, c.definitions)
+			}
+			for pointer, want := range c.values {
+				if got, ok := valueAt(doc, pointer); !ok || got != want {
+					t.Errorf("%s = %v, want %v", pointer, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBundleInlinedCycle(t *testing.T) {
+	// the parameters are inlined, so a cycle of them can not be kept as references
+	_, err := bundleFile(t, "cycle/parameters.yaml", LoadOption{})
+	want := "circular $ref: #/Limit"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %s", err, want)
+	}
+}
+
+func TestBundleUnchanged(t *testing.T) {
+	content, err := os.ReadFile("testdata/swagger.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Bundle(content, "testdata/swagger.yaml", LoadOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(content) {
+		t.Error("the content without references to other documents is changed")
+	}
+}
+
+func TestBundleRemoteRefs(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata/bundle/remote")))
+	defer srv.Close()
+	src := srv.URL + "/swagger.yaml"
+	content, err := ReadSource(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the relative refs of an url src are remote, which are off by default
+	_, err = decodeBundled(t, content, src, LoadOption{})
+	want := "resolve $ref models.yaml#/Pet failed: remote reference " + srv.URL + "/models.yaml is not allowed"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %s", err, want)
+	}
+
+	doc, err := decodeBundled(t, content, src, LoadOption{RemoteRefs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for pointer, want := range map[string]interface{}{
+		"/definitions/Pet/$ref":  "#/definitions/Pet2",
+		"/definitions/Pet2/type": "object",
+	} {
+		if got, ok := valueAt(doc, pointer); !ok || got != want {
+			t.Errorf("%s = %v, want %v", pointer, got, want)
+		}
+	}
+}
+
+func TestBundlerResolve(t *testing.T) {
+	location := filepath.FromSlash("/specs/v1/swagger.yaml")
+	cases := []struct {
+		name     string
+		location string
+		file     string
+		remote   bool
+		want     string
+		err      bool
+	}{
+		{name: "same document", location: location, file: "", want: location},
+		{name: "relative file", location: location, file: "models/pet.yaml", want: filepath.FromSlash("/specs/v1/models/pet.yaml")},
+		{name: "parent dir", location: location, file: "../common.yaml", want: filepath.FromSlash("/specs/common.yaml")},
+		{name: "absolute file", location: location, file: "/shared/./pet.yaml", want: filepath.FromSlash("/shared/pet.yaml")},
+		{name: "url off", location: location, file: "https://example.com/pet.yaml", err: true},
+		{name: "url", location: location, file: "https://example.com/pet.yaml", remote: true, want: "https://example.com/pet.yaml"},
+		{name: "relative to url off", location: "https://example.com/v1/swagger.yaml", file: "../pet.yaml", err: true},
+		{name: "relative to url", location: "https://example.com/v1/swagger.yaml", file: "../pet.yaml", remote: true, want: "https://example.com/pet.yaml"},
+	}
+	for _, c := range cases {
+		b := &bundler{opt: LoadOption{RemoteRefs: c.remote}}
+		got, err := b.resolve(c.location, c.file)
+		if c.err {
+			if err == nil {
+				t.Errorf("%s: resolved to %s, want an error", c.name, got)
+			}
+			continue
+		}
+		if err != nil || got != c.want {
+			t.Errorf("%s: resolve = %s, %v, want %s", c.name, got, err, c.want)
+		}
+	}
+}
+
+func TestBundlerName(t *testing.T) {
+	b := &bundler{
+		existing:    map[string]interface{}{"Pet": nil, "Pet2": nil},
+		definitions: map[string]interface{}{"Tag": nil},
+	}
+	cases := []struct {
+		location string
+		fragment string
+		want     string
+	}{
+		{location: "/specs/pet.yaml", fragment: "/Owner", want: "Owner"},
+		{location: "/specs/pet.yaml", fragment: "/definitions/Pet", want: "Pet3"},
+		{location: "/specs/tag.yaml", fragment: "/Tag/", want: "Tag2"},
+		{location: "/specs/pet.yaml", fragment: "/models/a~1b", want: "a.b"},
+		{location: "/specs/owner.yaml", fragment: "", want: "owner"},
+		{location: "https://example.com/models/user.json?v=1", fragment: "", want: "user"},
+	}
+	for _, c := range cases {
+		if got := b.name(filepath.FromSlash(c.location), c.fragment); got != c.want {
+			t.Errorf("name(%s, %s) = %s, want %s", c.location, c.fragment, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+
+	"github.com/zc2638/apidoc/resource"
+	"github.com/zc2638/apidoc/swag"
+)
+
+const changelogTemplateDir = "changelog"
+
+type changelogRenderer struct {
+	ext    string
+	render func(w io.Writer, cl *swag.Changelog, opt *RenderOption) error
+}
+
+var changelogRenderers = map[string]changelogRenderer{
+	FormatHTML:      {ext: ".html", render: renderChangelogHTML},
+	FormatPDF:       {ext: ".pdf", render: renderChangelogPDF},
+	FormatNativePDF: {ext: ".pdf", render: renderChangelogNativePDF},
+	FormatMarkdown:  {ext: ".md", render: renderChangelogMarkdown},
+}
+
+// ChangelogFormats returns the sorted names of the formats supported by RenderChangelog.
+func ChangelogFormats() []string {
+	names := make([]string, 0, len(changelogRenderers))
+	for name := range changelogRenderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ChangelogExtension returns the file extension of the changelog rendered in the format.
+func ChangelogExtension(format string) (string, bool) {
+	r, ok := changelogRenderers[format]
+	return r.ext, ok
+}
+
+// RenderChangelog renders the changelog in the format, which is one of ChangelogFormats.
+// The changes are grouped by operation and the breaking changes are flagged.
+func RenderChangelog(w io.Writer, format string, cl *swag.Changelog, opt *RenderOption) error {
+	r, ok := changelogRenderers[format]
+	if !ok {
+		return fmt.Errorf("unsupported changelog format: %s", format)
+	}
+	if opt == nil {
+		opt = &RenderOption{}
+	}
+	return r.render(w, cl, opt)
+}
+
+// changeGroup is the changes of an operation, or of a path for the added and removed paths.
+type changeGroup struct {
+	Title   string
+	Changes []swag.Change
+}
+
+func changeGroups(cl *swag.Changelog) []changeGroup {
+	var groups []changeGroup
+	index := make(map[string]int)
+	for _, c := range cl.Changes {
+		title := changeTitle(c)
+		i, ok := index[title]
+		if !ok {
+			i = len(groups)
+			index[title] = i
+			groups = append(groups, changeGroup{Title: title})
+		}
+		groups[i].Changes = append(groups[i].Changes, c)
+	}
+	return groups
+}
+
+func changeTitle(c swag.Change) string {
+	if c.Method == "" {
+		return c.Path
+	}
+	return c.Method + " " + c.Path
+}
+
+type changelogPage struct {
+	Log      *swag.Changelog
+	Groups   []changeGroup
+	Breaking []swag.Change
+}
+
+func renderChangelogHTML(w io.Writer, cl *swag.Changelog, _ *RenderOption) error {
+	data, err := changelogHTML(cl)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func changelogHTML(cl *swag.Changelog) ([]byte, error) {
+	files, err := resource.ReadTemplates(changelogTemplateDir)
+	if err != nil {
+		return nil, err
+	}
+	t, err := template.New("changelog").Funcs(funcMap).Funcs(template.FuncMap{
+		"changeTitle":      changeTitle,
+		"changelogHeading": changelogHeading,
+		"changelogSummary": changelogSummary,
+	}).Parse(string(files["changelog.html"]))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, &changelogPage{
+		Log:      cl,
+		Groups:   changeGroups(cl),
+		Breaking: cl.Breaking(),
+	})
+	return buf.Bytes(), err
+}
+
+func renderChangelogPDF(w io.Writer, cl *swag.Changelog, opt *RenderOption) error {
+	data, err := changelogHTML(cl)
+	if err != nil {
+		return err
+	}
+	pdfData, err := SaveToPDF(data, opt.Gray)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(pdfData)
+	return err
+}
+
+func renderChangelogNativePDF(w io.Writer, cl *swag.Changelog, opt *RenderOption) error {
+	pw, err := newPDFWriter(NativePDFOption{FontFile: opt.FontFile, Gray: opt.Gray})
+	if err != nil {
+		return err
+	}
+	pw.heading(1, changelogHeading(cl))
+	pw.paragraph(changelogSummary(cl))
+
+	widths := []float64{50, 55, 75}
+	if breaking := cl.Breaking(); len(breaking) > 0 {
+		pw.heading(2, "Breaking Changes")
+		rows := make([][]string, 0, len(breaking))
+		for _, c := range breaking {
+			rows = append(rows, []string{changeTitle(c), c.Location, c.Message})
+		}
+		pw.table([]string{"operation", "location", "description"}, widths, rows)
+	}
+	if len(cl.Changes) > 0 {
+		pw.heading(2, "Changes")
+	}
+	for _, g := range changeGroups(cl) {
+		pw.heading(3, g.Title)
+		rows := make([][]string, 0, len(g.Changes))
+		for _, c := range g.Changes {
+			message := c.Message
+			if c.Breaking {
+				message = "BREAKING " + message
+			}
+			rows = append(rows, []string{string(c.Type), c.Location, message})
+		}
+		pw.table([]string{"change", "location", "description"}, widths, rows)
+	}
+	if err := pw.pdf.Error(); err != nil {
+		return err
+	}
+	return pw.pdf.Output(w)
+}
+
+func renderChangelogMarkdown(w io.Writer, cl *swag.Changelog, _ *RenderOption) error {
+	bw := bufio.NewWriter(w)
+	md := &markdownWriter{w: bw}
+	md.heading(1, changelogHeading(cl))
+	md.paragraph(changelogSummary(cl))
+
+	if breaking := cl.Breaking(); len(breaking) > 0 {
+		md.heading(2, "Breaking Changes")
+		for _, c := range breaking {
+			line := fmt.Sprintf("- `%s`", changeTitle(c))
+			if c.Location != "" {
+				line += " " + c.Location
+			}
+			md.line(line + ": " + c.Message)
+		}
+		md.line("")
+	}
+	if len(cl.Changes) > 0 {
+		md.heading(2, "Changes")
+	}
+	for _, g := range changeGroups(cl) {
+		md.heading(3, "`"+g.Title+"`")
+		rows := make([][]string, 0, len(g.Changes))
+		for _, c := range g.Changes {
+			message := c.Message
+			if c.Breaking {
+				message = "**BREAKING** " + message
+			}
+			rows = append(rows, []string{string(c.Type), c.Location, message})
+		}
+		md.table([]string{"change", "location", "description"}, rows)
+	}
+	return bw.Flush()
+}
+
+func changelogHeading(cl *swag.Changelog) string {
+	title := "Changelog"
+	if cl.Title != "" {
+		title += ": " + cl.Title
+	}
+	if cl.BaseVersion != "" || cl.TargetVersion != "" {
+		title += fmt.Sprintf(" (%s -> %s)", cl.BaseVersion, cl.TargetVersion)
+	}
+	return title
+}
+
+func changelogSummary(cl *swag.Changelog) string {
+	if len(cl.Changes) == 0 {
+		return "No changes."
+	}
+	return fmt.Sprintf("%d changes, %d breaking.", len(cl.Changes), len(cl.Breaking()))
+}
@@ -0,0 +1,134 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+const changelogBase = `swagger: "2.0"
+info: {title: pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      parameters:
+      - {name: limit, in: query, type: integer}
+      responses:
+        "200":
+          description: ok
+          schema: {$ref: "#/definitions/Pet"}
+  /pets/{id}:
+    delete:
+      parameters:
+      - {name: id, in: path, type: string, required: true}
+      responses:
+        "204": {description: deleted}
+definitions:
+  Pet:
+    type: object
+    required: [id, name]
+    properties:
+      id: {type: integer}
+      name: {type: string}
+`
+
+// changelogTarget removes /pets/{id}, adds the optional parameter q and makes the name optional.
+var changelogTarget = strings.NewReplacer(
+	`version: "1.0"`, `version: "2.0"`,
+	"required: [id, name]", "required: [id]",
+	"- {name: limit, in: query, type: integer}", "- {name: limit, in: query, type: integer}\n      - {name: q, in: query, type: string}",
+).Replace(changelogBase[:strings.Index(changelogBase, "  /pets/{id}:")] + changelogBase[strings.Index(changelogBase, "definitions:"):])
+
+func changelog(t *testing.T, base, target string) *swag.Changelog {
+	t.Helper()
+	baseAPI, err := Load([]byte(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, err := Load([]byte(target))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return swag.Diff(baseAPI, api)
+}
+
+func TestRenderChangelogMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderChangelog(&buf, FormatMarkdown, changelog(t, changelogBase, changelogTarget), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Changelog: pets (1.0 -> 2.0)\n" +
+		"\n" +
+		"3 changes, 2 breaking.\n" +
+		"\n" +
+		"## Breaking Changes\n" +
+		"\n" +
+		"- `GET /pets` response 200 field name: field became optional\n" +
+		"- `/pets/{id}`: path removed\n" +
+		"\n" +
+		"## Changes\n" +
+		"\n" +
+		"### `GET /pets`\n" +
+		"\n" +
+		"| change | location | description |\n" +
+		"| --- | --- | --- |\n" +
+		"| parameter-added | query parameter q | optional parameter added |\n" +
+		"| field-optional | response 200 field name | **BREAKING** field became optional |\n" +
+		"\n" +
+		"### `/pets/{id}`\n" +
+		"\n" +
+		"| change | location | description |\n" +
+		"| --- | --- | --- |\n" +
+		"| path-removed |  | **BREAKING** path removed |\n" +
+		"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("markdown = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := RenderChangelog(&buf, FormatMarkdown, changelog(t, changelogBase, changelogBase), nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Changelog: pets (1.0 -> 1.0)\n\nNo changes.\n\n"; buf.String() != want {
+		t.Errorf("markdown without changes = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRenderChangelogHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderChangelog(&buf, FormatHTML, changelog(t, changelogBase, changelogTarget), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>Changelog: pets (1.0 -&gt; 2.0)</title>",
+		"<p class=\"summary\">3 changes, 2 breaking.</p>",
+		"<li><code>/pets/{id}</code> : path removed</li>",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("html does not contain %s", want)
+		}
+	}
+}
+
+func TestRenderChangelogFormat(t *testing.T) {
+	err := RenderChangelog(&bytes.Buffer{}, "site", &swag.Changelog{}, nil)
+	if want := "unsupported changelog format: site"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %s", err, want)
+	}
+}
@@ -15,28 +15,56 @@
 package app
 
 import (
+	"bytes"
 	"fmt"
-	"net/url"
 	"os"
 	"path/filepath"
-	"strings"
+	"runtime"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/zc2638/apidoc"
+	"github.com/zc2638/apidoc/swag"
 )
 
 const (
-	FormatPDF     = "pdf"
-	FormatGrayPDF = "gray-pdf"
+	FormatPDF      = "pdf"
+	FormatGrayPDF  = "gray-pdf"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatSite     = "site"
+)
+
+const (
+	EngineWkhtmltopdf = "wkhtmltopdf"
+	EngineNative      = "native"
 )
 
 type Option struct {
 	Template string
-	Src      string
+	Src      []string // files, globs, directories or urls.
 	Dest     string
 	Format   string // format, default is pdf.
+	Engine   string // pdf engine, default is wkhtmltopdf.
+	Font     string // font file embedded by the native pdf engine.
 	IsData   bool
+
+	ExampleSeed int64 // seed of the generated examples.
+	RemoteRefs  bool  // allows the http(s) references to other documents.
+
+	Concurrency int // max number of specs rendered concurrently in batch mode.
+
+	Merge          string            // merges the srcs into one document saved with the name.
+	Title          string            // title of the merged document.
+	GroupByService bool              // groups the operations of the merged document by service.
+	Prefixes       map[string]string // path prefixes of the merged services keyed by service name.
+
+	Watch         bool          // re-render when the src, its referred documents or template changes.
+	WatchAddr     string        // address serving the html with live reload in watch mode.
+	WatchDebounce time.Duration // quiet period waited for after a change before re-rendering.
+
+	batch bool // prefixes the messages with the src when several specs are rendered.
 }
 
 func NewServerCommand() *cobra.Command {
@@ -45,60 +73,158 @@ func NewServerCommand() *cobra.Command {
 		Use:          "apidoc",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if opt.Template != "default" {
-				return fmt.Errorf("custom templates will be supported soon")
+			if opt.Format == FormatSite && opt.IsData {
+				return fmt.Errorf("data mode is not supported by the %s format", FormatSite)
 			}
-			if opt.Format != FormatPDF && opt.Format != FormatGrayPDF {
-				return fmt.Errorf("other formats will be supported soon")
+			if _, err := rendererName(opt); err != nil {
+				return err
 			}
-
-			var data []byte
-			uri, err := url.Parse(opt.Src)
-			if err == nil && uri.Host != "" {
-				data, err = apidoc.ParseFromURL(opt.Src)
-				if err != nil {
-					return err
-				}
-			} else {
-				fileData, err := os.ReadFile(opt.Src)
-				if err != nil {
-					return fmt.Errorf("read src file failed: %v", err)
-				}
-				data, err = apidoc.Parse(fileData)
-				if err != nil {
-					return err
-				}
+			srcs, skipped, err := expandSources(opt.Src, apidoc.LoadOption{RemoteRefs: opt.RemoteRefs})
+			if err != nil {
+				return err
 			}
-
-			if opt.IsData {
-				pdfData, err := apidoc.SaveToPDF(data, opt.Format == FormatGrayPDF)
-				if err != nil {
-					return err
+			for _, s := range skipped {
+				fmt.Fprintf(os.Stderr, "skipped %s, it is referred by %s\n", s.Src, s.Referrer)
+			}
+			if len(srcs) == 0 {
+				return fmt.Errorf("src is required")
+			}
+			if opt.Merge != "" {
+				if opt.Watch {
+					return fmt.Errorf("watch mode is not supported in merge mode")
 				}
-				os.Stdout.Write(pdfData)
-				return nil
+				return merge(opt, srcs)
 			}
-
-			if err := os.MkdirAll(opt.Dest, os.ModePerm); err != nil {
-				return fmt.Errorf("create dest dir failed: %v", err)
+			if len(srcs) > 1 {
+				if opt.IsData {
+					return fmt.Errorf("data mode is not supported with multiple srcs")
+				}
+				if opt.Watch {
+					return fmt.Errorf("watch mode is not supported with multiple srcs")
+				}
+				return batch(opt, srcs)
 			}
-			base := filepath.Base(opt.Src)
-			ext := filepath.Ext(base)
-			target := strings.TrimSuffix(base, ext) + ".pdf"
-			if err := apidoc.SaveToPDFFile(data, opt.Format == FormatGrayPDF, filepath.Join(opt.Dest, target)); err != nil {
-				return fmt.Errorf("save failed: %v", err)
+			if opt.Watch {
+				return watch(cmd.Context(), opt, srcs[0])
 			}
-			return nil
+			_, err = generate(opt, srcs[0])
+			return err
 		},
 	}
 	completionFlags(cmd, opt)
+	cmd.AddCommand(NewServeCommand(), NewDiffCommand(), NewBreakingCommand(), NewValidateCommand(), NewLintCommand(), NewCoverageCommand())
 	return cmd
 }
 
+// generate renders the src with the option and saves the result to the dest,
+// the rendered data is returned except for the site format.
+func generate(opt *Option, src string) ([]byte, error) {
+	return generateAs(opt, src, outputName(src, ""))
+}
+
+// generateAs is generate saving the result with the base name.
+func generateAs(opt *Option, src, base string) ([]byte, error) {
+	api, err := load(opt, src)
+	if err != nil {
+		return nil, err
+	}
+	return output(opt, api, base)
+}
+
+// load reads and parses the src with the documents it refers to, and reports the warnings.
+func load(opt *Option, src string) (*swag.API, error) {
+	api, err := apidoc.LoadSource(src, apidoc.LoadOption{RemoteRefs: opt.RemoteRefs})
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range api.Warnings() {
+		if opt.batch {
+			warning = src + ": " + warning
+		}
+		fmt.Fprintln(os.Stderr, "warning:", warning)
+	}
+	return api, nil
+}
+
+// output renders the api and saves it to the dest with the base name.
+func output(opt *Option, api *swag.API, base string) ([]byte, error) {
+	name, err := rendererName(opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.ExampleSeed != swag.DefaultExampleSeed {
+		if err := api.SetExampleGenerator(swag.NewExampleGenerator(opt.ExampleSeed)); err != nil {
+			return nil, err
+		}
+	}
+	if opt.Format == FormatSite {
+		return nil, apidoc.GenerateSite(api, filepath.Join(opt.Dest, base))
+	}
+
+	r, ok := apidoc.GetRenderer(name)
+	if !ok {
+		return nil, fmt.Errorf("renderer %s not found", name)
+	}
+	renderOpt := &apidoc.RenderOption{
+		Template: opt.Template,
+		Gray:     opt.Format == FormatGrayPDF,
+		FontFile: opt.Font,
+	}
+
+	if opt.IsData {
+		return nil, r.Render(os.Stdout, api, renderOpt)
+	}
+
+	var buf bytes.Buffer
+	if err := r.Render(&buf, api, renderOpt); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(opt.Dest, 0o755); err != nil {
+		return nil, fmt.Errorf("create dest dir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(opt.Dest, base+r.Extension()), buf.Bytes(), 0o644); err != nil {
+		return nil, fmt.Errorf("save failed: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// rendererName returns the name of the renderer for the output format.
+func rendererName(opt *Option) (string, error) {
+	switch opt.Format {
+	case FormatPDF, FormatGrayPDF:
+		switch opt.Engine {
+		case EngineWkhtmltopdf:
+			return apidoc.FormatPDF, nil
+		case EngineNative:
+			return apidoc.FormatNativePDF, nil
+		}
+		return "", fmt.Errorf("unsupported pdf engine: %s", opt.Engine)
+	case FormatMarkdown:
+		return apidoc.FormatMarkdown, nil
+	case FormatHTML:
+		return apidoc.FormatHTML, nil
+	case FormatSite:
+		return apidoc.FormatSite, nil
+	}
+	return "", fmt.Errorf("unsupported format: %s", opt.Format)
+}
+
 func completionFlags(cmd *cobra.Command, opt *Option) {
-	cmd.Flags().StringVar(&opt.Template, "template", "default", "Specify the template file, the built-in `default` is used by default")
-	cmd.Flags().StringVar(&opt.Format, "format", FormatPDF, "Specify the output file format(pdf、gray-pdf), the default is pdf")
-	cmd.Flags().StringVar(&opt.Src, "src", "", "Specify the swagger configuration file path")
+	cmd.Flags().StringVar(&opt.Template, "template", apidoc.DefaultTemplate, "Specify the template file or directory, or the built-in template(default、table), the default is default")
+	cmd.Flags().StringVar(&opt.Format, "format", FormatPDF, "Specify the output file format(pdf、gray-pdf、markdown、html、site), the default is pdf")
+	cmd.Flags().StringVar(&opt.Engine, "engine", EngineWkhtmltopdf, "Specify the pdf engine(wkhtmltopdf、native), the native engine does not require wkhtmltopdf but ignores the template")
+	cmd.Flags().StringVar(&opt.Font, "font", "", "Specify the TrueType font file embedded by the native engine instead of the built-in CJK font")
+	cmd.Flags().StringSliceVar(&opt.Src, "src", nil, "Specify the swagger configuration file paths, globs, directories or urls, separated by commas or repeated")
+	cmd.Flags().BoolVar(&opt.RemoteRefs, "remote-refs", false, "Specify whether the http(s) $ref to other documents are resolved, including the relative $ref of an url src")
+	cmd.Flags().Int64Var(&opt.ExampleSeed, "example-seed", swag.DefaultExampleSeed, "Specify the seed of the examples generated for the schemas without examples")
+	cmd.Flags().IntVar(&opt.Concurrency, "concurrency", runtime.NumCPU(), "Specify the max number of specs rendered concurrently in batch mode")
 	cmd.Flags().StringVar(&opt.Dest, "dest", "dist", "Specify output path.")
 	cmd.Flags().BoolVar(&opt.IsData, "data", false, "Specify data mode output.")
+	cmd.Flags().StringVar(&opt.Merge, "merge", "", "Specify merge mode, the srcs are merged into one document saved with the name")
+	cmd.Flags().StringVar(&opt.Title, "title", "API Reference", "Specify the title of the merged document")
+	cmd.Flags().BoolVar(&opt.GroupByService, "group-by-service", false, "Specify whether the operations of the merged document are grouped by service instead of by tag")
+	cmd.Flags().StringToStringVar(&opt.Prefixes, "prefix", nil, "Specify the path prefix of a merged service as service=path, separated by commas or repeated, the base path is used by default")
+	cmd.Flags().BoolVar(&opt.Watch, "watch", false, "Specify watch mode, re-render when the src, its referred documents or template files change")
+	cmd.Flags().StringVar(&opt.WatchAddr, "watch-addr", "127.0.0.1:8090", "Specify the address serving the html with live reload in watch mode, empty disables it")
+	cmd.Flags().DurationVar(&opt.WatchDebounce, "watch-debounce", 300*time.Millisecond, "Specify the quiet period after a change before re-rendering in watch mode")
 }
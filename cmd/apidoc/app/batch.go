@@ -0,0 +1,288 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zc2638/apidoc"
+)
+
+// specExtensions are the file extensions of the specs found in the src directories.
+var specExtensions = map[string]struct{}{
+	".json": {},
+	".yaml": {},
+	".yml":  {},
+}
+
+// expandSources expands the globs and directories of the srcs to spec files,
+// urls are kept as they are and the duplicates are removed.
+// The files found by the globs and directories are skipped if another spec refers to them with $ref,
+// such as the shared models, since they are fragments rather than specs.
+func expandSources(srcs []string, opt apidoc.LoadOption) ([]string, []skippedSource, error) {
+	var out []string
+	seen := make(map[string]struct{})
+	found := make(map[string]struct{})
+	add := func(src string) {
+		if _, ok := seen[src]; ok {
+			return
+		}
+		seen[src] = struct{}{}
+		out = append(out, src)
+	}
+	addFound := func(src string) {
+		if _, ok := seen[src]; !ok {
+			found[src] = struct{}{}
+		}
+		add(src)
+	}
+
+	for _, src := range srcs {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			continue
+		}
+		if apidoc.IsURL(src) {
+			add(src)
+			continue
+		}
+		if strings.ContainsAny(src, "*?[") {
+			matches, err := filepath.Glob(src)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid src pattern %s: %v", src, err)
+			}
+			if len(matches) == 0 {
+				return nil, nil, fmt.Errorf("no src files match %s", src)
+			}
+			for _, match := range matches {
+				addFound(match)
+			}
+			continue
+		}
+
+		info, err := os.Stat(src)
+		if err != nil || !info.IsDir() {
+			// the missing file is reported when it is read
+			add(src)
+			continue
+		}
+		err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if _, ok := specExtensions[strings.ToLower(filepath.Ext(path))]; ok && !d.IsDir() {
+				addFound(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("read src dir failed: %v", err)
+		}
+	}
+	if len(found) == 0 || len(out) < 2 {
+		return out, nil, nil
+	}
+	out, skipped := skipReferred(out, found, opt)
+	return out, skipped, nil
+}
+
+// skippedSource is a found file skipped since the Referrer refers to it.
+type skippedSource struct {
+	Src      string
+	Referrer string
+}
+
+// skipReferred removes the found files referred by another src,
+// unless they refer to each other. The src failed to load is reported when it is rendered.
+func skipReferred(srcs []string, found map[string]struct{}, opt apidoc.LoadOption) ([]string, []skippedSource) {
+	locations := make([]string, len(srcs))
+	refs := make([]map[string]struct{}, len(srcs))
+	for i, src := range srcs {
+		locations[i] = src
+		if apidoc.IsURL(src) {
+			continue
+		}
+		if abs, err := filepath.Abs(src); err == nil {
+			locations[i] = abs
+		}
+		sources, _ := apidoc.Sources(src, opt)
+		refs[i] = make(map[string]struct{}, len(sources))
+		for _, source := range sources {
+			refs[i][source] = struct{}{}
+		}
+	}
+	refers := func(i, j int) bool {
+		_, ok := refs[i][locations[j]]
+		return i != j && ok
+	}
+
+	out := make([]string, 0, len(srcs))
+	var skipped []skippedSource
+	for j, src := range srcs {
+		referrer := -1
+		if _, ok := found[src]; ok {
+			for i := range srcs {
+				if refers(i, j) && !refers(j, i) {
+					referrer = i
+					break
+				}
+			}
+		}
+		if referrer >= 0 {
+			skipped = append(skipped, skippedSource{Src: src, Referrer: srcs[referrer]})
+			continue
+		}
+		out = append(out, src)
+	}
+	return out, skipped
+}
+
+// outputName returns the output file name of the src, which is its base name with the extension.
+func outputName(src, ext string) string {
+	base := filepath.Base(src)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ext
+}
+
+// batchNames returns the output names of the srcs, which are their base names without the extensions.
+// The srcs sharing a name are prefixed by as many parent directories as needed to tell them apart,
+// e.g. `users-swagger` and `orders-swagger` for `services/users/swagger.yaml` and `services/orders/swagger.yaml`.
+func batchNames(srcs []string) ([]string, error) {
+	elems := make([][]string, len(srcs))
+	levels := make([]int, len(srcs))
+	names := make([]string, len(srcs))
+	for i, src := range srcs {
+		elems[i] = srcElements(src)
+		levels[i] = 1
+	}
+	for {
+		owners := make(map[string][]int, len(srcs))
+		for i := range srcs {
+			e := elems[i]
+			names[i] = strings.Join(e[len(e)-levels[i]:], "-")
+			owners[names[i]] = append(owners[names[i]], i)
+		}
+		clashed := false
+		for _, i := range sortedIndexes(owners) {
+			idx := owners[names[i]]
+			if len(idx) < 2 {
+				continue
+			}
+			extended := false
+			for _, j := range idx {
+				if levels[j] < len(elems[j]) {
+					levels[j]++
+					extended = true
+				}
+			}
+			if !extended {
+				// the outputs would overwrite each other
+				return nil, fmt.Errorf("%s and %s are both rendered to %s in the dest", srcs[idx[0]], srcs[idx[1]], names[i])
+			}
+			clashed = true
+		}
+		if !clashed {
+			return names, nil
+		}
+	}
+}
+
+// srcElements returns the path elements of the src, the last one is its base name without the extension.
+func srcElements(src string) []string {
+	p := filepath.ToSlash(filepath.Clean(src))
+	if apidoc.IsURL(src) {
+		if u, err := url.Parse(src); err == nil {
+			p = u.Path
+		}
+	}
+	var elems []string
+	for _, elem := range strings.Split(p, "/") {
+		if elem != "" && elem != "." && elem != ".." {
+			elems = append(elems, elem)
+		}
+	}
+	if len(elems) == 0 {
+		return []string{outputName(src, "")}
+	}
+	elems[len(elems)-1] = outputName(elems[len(elems)-1], "")
+	return elems
+}
+
+// sortedIndexes returns the first index of each owned name in order.
+func sortedIndexes(owners map[string][]int) []int {
+	out := make([]int, 0, len(owners))
+	for _, idx := range owners {
+		out = append(out, idx[0])
+	}
+	sort.Ints(out)
+	return out
+}
+
+// batch renders the srcs concurrently with at most opt.Concurrency workers,
+// the failures are summarized after all the srcs are done.
+func batch(opt *Option, srcs []string) error {
+	names, err := batchNames(srcs)
+	if err != nil {
+		return err
+	}
+
+	workers := opt.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(srcs) {
+		workers = len(srcs)
+	}
+
+	bopt := *opt
+	bopt.batch = true
+
+	errs := make([]error, len(srcs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				_, errs[j] = generateAs(&bopt, srcs[j], names[j])
+			}
+		}()
+	}
+	for i := range srcs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "failed: %s: %v\n", srcs[i], err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "rendered %d of %d specs\n", len(srcs)-failed, len(srcs))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d specs failed", failed, len(srcs))
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zc2638/apidoc"
+)
+
+const (
+	petAPI = `swagger: "2.0"
+info: {title: pets, version: "1"}
+paths: {}
+definitions:
+  Pet:
+    $ref: models.yaml#/Pet
+`
+	petModels = `Pet:
+  type: object
+`
+	// pingAPI and pongAPI refer to each other
+	pingAPI = `swagger: "2.0"
+info: {title: ping, version: "1"}
+paths: {}
+definitions:
+  Ping: {type: string}
+  Pong:
+    $ref: pong.yaml#/definitions/Pong
+`
+	pongAPI = `swagger: "2.0"
+info: {title: pong, version: "1"}
+paths: {}
+definitions:
+  Pong: {type: string}
+  Ping:
+    $ref: ping.yaml#/definitions/Ping
+`
+)
+
+// writeFiles writes the files into a temporary directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExpandSources(t *testing.T) {
+	cases := []struct {
+		name    string
+		files   map[string]string
+		srcs    []string
+		want    []string
+		skipped []skippedSource
+	}{
+		{
+			name:  "dir",
+			files: map[string]string{"a.yaml": "", "b.JSON": "", "notes.txt": "", "v2/c.yml": ""},
+			srcs:  []string{"."},
+			want:  []string{"a.yaml", "b.JSON", "v2/c.yml"},
+		},
+		{
+			name:  "glob",
+			files: map[string]string{"a.yaml": "", "b.yaml": "", "c.json": ""},
+			srcs:  []string{"*.yaml"},
+			want:  []string{"a.yaml", "b.yaml"},
+		},
+		{
+			name:  "duplicates",
+			files: map[string]string{"a.yaml": "", "b.yaml": ""},
+			srcs:  []string{"b.yaml", "*.yaml", ".", " ", "b.yaml"},
+			want:  []string{"b.yaml", "a.yaml"},
+		},
+		{
+			name:  "urls and missing files",
+			files: map[string]string{"a.yaml": ""},
+			srcs:  []string{"https://example.com/swagger.json", "missing.yaml", "a.yaml"},
+			want:  []string{"https://example.com/swagger.json", "missing.yaml", "a.yaml"},
+		},
+		{
+			name:    "referred file found in dir",
+			files:   map[string]string{"api.yaml": petAPI, "models.yaml": petModels},
+			srcs:    []string{"."},
+			want:    []string{"api.yaml"},
+			skipped: []skippedSource{{Src: "models.yaml", Referrer: "api.yaml"}},
+		},
+		{
+			name:  "referred file given explicitly",
+			files: map[string]string{"api.yaml": petAPI, "models.yaml": petModels},
+			srcs:  []string{"api.yaml", "models.yaml"},
+			want:  []string{"api.yaml", "models.yaml"},
+		},
+		{
+			name:  "mutual refs",
+			files: map[string]string{"ping.yaml": pingAPI, "pong.yaml": pongAPI},
+			srcs:  []string{"*.yaml"},
+			want:  []string{"ping.yaml", "pong.yaml"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := writeFiles(t, c.files)
+			// the srcs and the results are relative to the dir
+			abs := func(name string) string {
+				if apidoc.IsURL(name) || strings.TrimSpace(name) == "" {
+					return name
+				}
+				return filepath.Join(dir, filepath.FromSlash(name))
+			}
+			srcs := make([]string, 0, len(c.srcs))
+			for _, src := range c.srcs {
+				srcs = append(srcs, abs(src))
+			}
+			want := make([]string, 0, len(c.want))
+			for _, name := range c.want {
+				want = append(want, abs(name))
+			}
+			var skipped []skippedSource
+			for _, s := range c.skipped {
+				skipped = append(skipped, skippedSource{Src: abs(s.Src), Referrer: abs(s.Referrer)})
+			}
+
+			got, gotSkipped, err := expandSources(srcs, apidoc.LoadOption{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("srcs = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(gotSkipped, skipped) {
+				t.Errorf("skipped = %+v, want %+v", gotSkipped, skipped)
+			}
+		})
+	}
+}
+
+func TestExpandSourcesErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a.yaml": ""})
+	for _, src := range []string{
+		filepath.Join(dir, "*.json"),
+		filepath.Join(dir, "[a.yaml"),
+	} {
+		if _, _, err := expandSources([]string{src}, apidoc.LoadOption{}); err == nil {
+			t.Errorf("%s is expanded without an error", src)
+		}
+	}
+}
+
+func TestBatchNames(t *testing.T) {
+	cases := []struct {
+		srcs []string
+		want []string
+	}{
+		{
+			srcs: []string{"a.yaml", "docs/b.json", "https://example.com/specs/c.yml?v=1"},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			srcs: []string{"services/users/swagger.yaml", "services/orders/swagger.yaml", "billing.yaml"},
+			want: []string{"users-swagger", "orders-swagger", "billing"},
+		},
+		{
+			srcs: []string{"v1/admin/api.yaml", "v2/admin/api.json", "v2/api.yaml"},
+			want: []string{"v1-admin-api", "v2-admin-api", "v2-api"},
+		},
+		{
+			srcs: []string{"api.yaml", "v1/api.yaml", "https://example.com/v2/api.json"},
+			want: []string{"api", "v1-api", "v2-api"},
+		},
+	}
+	for _, c := range cases {
+		got, err := batchNames(c.srcs)
+		if err != nil {
+			t.Errorf("%v: %v", c.srcs, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("names of %v = %v, want %v", c.srcs, got, c.want)
+		}
+	}
+
+	// nothing tells them apart
+	_, err := batchNames([]string{"api.yaml", "./api.json"})
+	want := "api.yaml and ./api.json are both rendered to api in the dest"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %s", err, want)
+	}
+}
+
+func TestBatchGlob(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"services/users/swagger.yaml":  strings.ReplaceAll(petAPI, "pets", "users"),
+		"services/users/models.yaml":   petModels,
+		"services/orders/swagger.yaml": strings.ReplaceAll(petAPI, "pets", "orders"),
+		"services/orders/models.yaml":  petModels,
+	})
+	srcs, _, err := expandSources([]string{filepath.Join(dir, "services", "*", "swagger.yaml")}, apidoc.LoadOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(dir, "dist")
+	if err := batch(&Option{Format: FormatMarkdown, Dest: dest, Concurrency: 2}, srcs); err != nil {
+		t.Fatal(err)
+	}
+	for name, title := range map[string]string{"orders-swagger.md": "orders", "users-swagger.md": "users"} {
+		content, err := os.ReadFile(filepath.Join(dest, name))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !strings.Contains(string(content), title) {
+			t.Errorf("%s is not rendered from the %s spec", name, title)
+		}
+	}
+}
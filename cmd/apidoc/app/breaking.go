@@ -0,0 +1,138 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+// ExitBreaking is the exit code of the breaking command if any breaking change is found,
+// other errors such as a spec failed to load exit with 1.
+const ExitBreaking = 2
+
+// ExitError is an error which exits the program with the code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+type BreakingOption struct {
+	Base   string
+	Src    string
+	Output string // file of the json report, the report is written to stdout if it is empty.
+}
+
+// BreakingReport is the machine-readable report of the breaking changes.
+type BreakingReport struct {
+	Base          string `json:"base"`
+	Src           string `json:"src"`
+	BaseVersion   string `json:"baseVersion"`
+	TargetVersion string `json:"targetVersion"`
+
+	// Breaking reports whether any breaking change exists.
+	Breaking bool `json:"breaking"`
+
+	// Summary counts the breaking changes by category.
+	Summary map[swag.BreakingCategory]int `json:"summary"`
+
+	// NonBreaking is the number of the other changes.
+	NonBreaking int `json:"nonBreaking"`
+
+	Changes []swag.Change `json:"changes"`
+}
+
+func NewBreakingCommand() *cobra.Command {
+	opt := &BreakingOption{}
+	cmd := &cobra.Command{
+		Use:          "breaking",
+		Short:        "Detect the breaking changes between two versions of a spec, exit with 2 if any exists",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, err := diff(opt.Base, opt.Src)
+			if err != nil {
+				return err
+			}
+			report := newBreakingReport(opt, cl)
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			data = append(data, '\n')
+
+			if opt.Output == "" {
+				if _, err := os.Stdout.Write(data); err != nil {
+					return err
+				}
+			} else {
+				if err := os.MkdirAll(filepath.Dir(opt.Output), 0o755); err != nil {
+					return fmt.Errorf("create report dir failed: %v", err)
+				}
+				if err := os.WriteFile(opt.Output, data, 0o644); err != nil {
+					return fmt.Errorf("save failed: %v", err)
+				}
+			}
+
+			for _, c := range report.Changes {
+				where := strings.Join(strings.Fields(c.Method+" "+c.Path+" "+c.Location), " ")
+				fmt.Fprintf(os.Stderr, "breaking: [%s] %s: %s\n", c.Category, where, c.Message)
+			}
+			if report.Breaking {
+				return &ExitError{Code: ExitBreaking, Err: fmt.Errorf("%d breaking changes found", len(report.Changes))}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opt.Base, "base", "", "Specify the swagger configuration file path or url of the base version")
+	cmd.Flags().StringVar(&opt.Src, "src", "", "Specify the swagger configuration file path or url of the new version")
+	cmd.Flags().StringVar(&opt.Output, "output", "", "Specify the file of the json report, the default is stdout")
+	return cmd
+}
+
+func newBreakingReport(opt *BreakingOption, cl *swag.Changelog) *BreakingReport {
+	breaking := cl.Breaking()
+	report := &BreakingReport{
+		Base:          opt.Base,
+		Src:           opt.Src,
+		BaseVersion:   cl.BaseVersion,
+		TargetVersion: cl.TargetVersion,
+		Breaking:      len(breaking) > 0,
+		Summary:       make(map[swag.BreakingCategory]int),
+		NonBreaking:   len(cl.Changes) - len(breaking),
+		Changes:       breaking,
+	}
+	if report.Changes == nil {
+		report.Changes = []swag.Change{}
+	}
+	for _, c := range breaking {
+		report.Summary[c.Category]++
+	}
+	sort.SliceStable(report.Changes, func(i, j int) bool {
+		return report.Changes[i].Category < report.Changes[j].Category
+	})
+	return report
+}
@@ -0,0 +1,110 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+const breakingBase = `swagger: "2.0"
+info: {title: pets, version: "1"}
+paths:
+  /pets:
+    get:
+      parameters:
+      - {name: status, in: query, type: string, enum: [available, sold]}
+      responses:
+        "200": {description: ok}
+  /pets/{id}:
+    delete:
+      parameters:
+      - {name: id, in: path, type: string, required: true}
+      responses:
+        "204": {description: deleted}
+`
+
+func TestBreakingCommand(t *testing.T) {
+	cases := []struct {
+		name     string
+		target   string
+		code     int
+		breaking bool
+		summary  map[swag.BreakingCategory]int
+	}{
+		{
+			name:   "unchanged",
+			target: breakingBase,
+		},
+		{
+			name:   "added path",
+			target: breakingBase + "  /owners:\n    get:\n      responses:\n        \"200\": {description: ok}\n",
+		},
+		{
+			name:     "removed operation and narrowed enum",
+			target:   strings.Replace(breakingBase[:strings.Index(breakingBase, "  /pets/{id}:")], "[available, sold]", "[available]", 1),
+			code:     ExitBreaking,
+			breaking: true,
+			summary:  map[swag.BreakingCategory]int{swag.RemovedEndpoint: 1, swag.NarrowedEnum: 1},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"base.yaml": breakingBase, "target.yaml": c.target})
+			output := filepath.Join(dir, "report", "breaking.json")
+			cmd := NewBreakingCommand()
+			cmd.SetArgs([]string{
+				"--base", filepath.Join(dir, "base.yaml"),
+				"--src", filepath.Join(dir, "target.yaml"),
+				"--output", output,
+			})
+			err := cmd.Execute()
+
+			var exitErr *ExitError
+			switch {
+			case c.code == 0 && err != nil:
+				t.Fatalf("error = %v, want nil", err)
+			case c.code != 0 && (!errors.As(err, &exitErr) || exitErr.Code != c.code):
+				t.Fatalf("error = %v, want exit code %d", err, c.code)
+			}
+
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var report BreakingReport
+			if err := json.Unmarshal(data, &report); err != nil {
+				t.Fatal(err)
+			}
+			if report.Breaking != c.breaking {
+				t.Errorf("breaking = %v, want %v", report.Breaking, c.breaking)
+			}
+			for category, n := range c.summary {
+				if report.Summary[category] != n {
+					t.Errorf("summary[%s] = %d, want %d", category, report.Summary[category], n)
+				}
+			}
+			if len(report.Summary) != len(c.summary) {
+				t.Errorf("summary = %v, want %v", report.Summary, c.summary)
+			}
+		})
+	}
+}
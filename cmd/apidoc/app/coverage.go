@@ -0,0 +1,122 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zc2638/apidoc"
+	"github.com/zc2638/apidoc/swag"
+)
+
+type CoverageOption struct {
+	Src       string
+	Output    string
+	Top       int
+	Threshold float64 // the minimum total percent, disabled if it is 0
+
+	RemoteRefs bool // allows the http(s) references to other documents.
+}
+
+func NewCoverageCommand() *cobra.Command {
+	opt := &CoverageOption{}
+	cmd := &cobra.Command{
+		Use:          "coverage",
+		Short:        "Report how many operations, parameters and properties have descriptions and examples",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opt.Src == "" {
+				return fmt.Errorf("src is required")
+			}
+			api, err := apidoc.LoadSource(opt.Src, apidoc.LoadOption{RemoteRefs: opt.RemoteRefs})
+			if err != nil {
+				return err
+			}
+			report := swag.Coverage(api)
+
+			switch opt.Output {
+			case OutputText:
+				if err := writeCoverage(os.Stdout, opt, report); err != nil {
+					return err
+				}
+			case OutputJSON:
+				if opt.Top > 0 && len(report.Offenders) > opt.Top {
+					report.Offenders = report.Offenders[:opt.Top]
+				}
+				if err := writeJSON(os.Stdout, report); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unsupported output: %s", opt.Output)
+			}
+
+			if opt.Threshold > 0 && report.Total.Percent < opt.Threshold {
+				return fmt.Errorf("coverage %.1f%% is below the threshold %.1f%%", report.Total.Percent, opt.Threshold)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opt.Src, "src", "", "Specify the swagger configuration file path or url")
+	cmd.Flags().StringVar(&opt.Output, "output", OutputText, "Specify the output format, supports text, json")
+	cmd.Flags().IntVar(&opt.Top, "top", 10, "Specify the number of the worst offenders listed, 0 lists all")
+	cmd.Flags().Float64Var(&opt.Threshold, "threshold", 0, "Specify the minimum total coverage percent, the command fails below it")
+	cmd.Flags().BoolVar(&opt.RemoteRefs, "remote-refs", false, "Specify whether the http(s) $ref to other documents are resolved")
+	return cmd
+}
+
+func writeCoverage(w io.Writer, opt *CoverageOption, report *swag.CoverageReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Documentation coverage of %s\n\n", opt.Src)
+	fmt.Fprintln(tw, "\tdescription\texample")
+	for _, row := range []struct {
+		name     string
+		category swag.CoverageCategory
+	}{
+		{"operations", report.Operations},
+		{"parameters", report.Parameters},
+		{"properties", report.Properties},
+	} {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", row.name, coverageMetric(row.category.Description), coverageMetric(row.category.Example))
+	}
+	fmt.Fprintf(tw, "total\t%s\n", coverageMetric(report.Total))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	offenders := report.Offenders
+	if opt.Top > 0 && len(offenders) > opt.Top {
+		offenders = offenders[:opt.Top]
+	}
+	if len(offenders) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "\nWorst offenders:")
+	for _, o := range offenders {
+		fmt.Fprintf(w, "  %s %s: %d missing\n", o.Kind, o.Name, len(o.Missing))
+		for _, m := range o.Missing {
+			fmt.Fprintf(w, "    - %s\n", m)
+		}
+	}
+	return nil
+}
+
+func coverageMetric(m swag.CoverageMetric) string {
+	return fmt.Sprintf("%.1f%% (%d/%d)", m.Percent, m.Covered, m.Total)
+}
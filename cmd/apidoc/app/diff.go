@@ -0,0 +1,105 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zc2638/apidoc"
+	"github.com/zc2638/apidoc/swag"
+)
+
+type DiffOption struct {
+	Base   string
+	Src    string
+	Dest   string
+	Format string // format, default is markdown.
+	Engine string // pdf engine, default is wkhtmltopdf.
+	Font   string // font file embedded by the native pdf engine.
+	IsData bool
+}
+
+func NewDiffCommand() *cobra.Command {
+	opt := &DiffOption{}
+	cmd := &cobra.Command{
+		Use:          "diff",
+		Short:        "Render the changelog between two versions of a spec",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, err := rendererName(&Option{Format: opt.Format, Engine: opt.Engine})
+			if err != nil {
+				return err
+			}
+			ext, ok := apidoc.ChangelogExtension(name)
+			if !ok {
+				return fmt.Errorf("unsupported changelog format: %s", opt.Format)
+			}
+			cl, err := diff(opt.Base, opt.Src)
+			if err != nil {
+				return err
+			}
+			renderOpt := &apidoc.RenderOption{
+				Gray:     opt.Format == FormatGrayPDF,
+				FontFile: opt.Font,
+			}
+
+			if opt.IsData {
+				return apidoc.RenderChangelog(os.Stdout, name, cl, renderOpt)
+			}
+			var buf bytes.Buffer
+			if err := apidoc.RenderChangelog(&buf, name, cl, renderOpt); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(opt.Dest, 0o755); err != nil {
+				return fmt.Errorf("create dest dir failed: %v", err)
+			}
+			target := outputName(opt.Src, "-changelog"+ext)
+			if err := os.WriteFile(filepath.Join(opt.Dest, target), buf.Bytes(), 0o644); err != nil {
+				return fmt.Errorf("save failed: %v", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opt.Base, "base", "", "Specify the swagger configuration file path or url of the base version")
+	cmd.Flags().StringVar(&opt.Src, "src", "", "Specify the swagger configuration file path or url of the new version")
+	cmd.Flags().StringVar(&opt.Dest, "dest", "dist", "Specify output path.")
+	cmd.Flags().StringVar(&opt.Format, "format", FormatMarkdown, "Specify the output file format(pdf、gray-pdf、markdown、html), the default is markdown")
+	cmd.Flags().StringVar(&opt.Engine, "engine", EngineWkhtmltopdf, "Specify the pdf engine(wkhtmltopdf、native)")
+	cmd.Flags().StringVar(&opt.Font, "font", "", "Specify the TrueType font file embedded by the native engine")
+	cmd.Flags().BoolVar(&opt.IsData, "data", false, "Specify data mode output.")
+	return cmd
+}
+
+// diff loads the base and the src, and compares them.
+func diff(base, src string) (*swag.Changelog, error) {
+	if base == "" || src == "" {
+		return nil, fmt.Errorf("both base and src are required")
+	}
+	opt := &Option{}
+	baseAPI, err := load(opt, base)
+	if err != nil {
+		return nil, fmt.Errorf("load base failed: %v", err)
+	}
+	api, err := load(opt, src)
+	if err != nil {
+		return nil, fmt.Errorf("load src failed: %v", err)
+	}
+	return swag.Diff(baseAPI, api), nil
+}
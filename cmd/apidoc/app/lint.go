@@ -0,0 +1,230 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zc2638/apidoc"
+	"github.com/zc2638/apidoc/swag"
+)
+
+const OutputSARIF = "sarif"
+
+type LintOption struct {
+	Src    string
+	Config string
+	Output string
+
+	RemoteRefs bool // allows the http(s) references to other documents.
+}
+
+func NewLintCommand() *cobra.Command {
+	opt := &LintOption{}
+	cmd := &cobra.Command{
+		Use:          "lint",
+		Short:        "Check the spec against the style rules, exit non-zero if any error is found",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opt.Src == "" {
+				return fmt.Errorf("src is required")
+			}
+			var cfg *swag.LintConfig
+			if opt.Config != "" {
+				content, err := os.ReadFile(opt.Config)
+				if err != nil {
+					return fmt.Errorf("read lint config failed: %v", err)
+				}
+				if cfg, err = apidoc.LoadLintConfig(content); err != nil {
+					return err
+				}
+			}
+			results, err := apidoc.LintSource(opt.Src, cfg, apidoc.LoadOption{RemoteRefs: opt.RemoteRefs})
+			if err != nil {
+				return err
+			}
+
+			switch opt.Output {
+			case OutputText:
+				for _, r := range results {
+					fmt.Printf("%s:%d: %s [%s] %s: %s\n", opt.Src, r.Line, r.Severity, r.Rule, r.Pointer, r.Message)
+				}
+			case OutputJSON:
+				if results == nil {
+					results = []swag.LintResult{}
+				}
+				if err := writeJSON(os.Stdout, results); err != nil {
+					return err
+				}
+			case OutputSARIF:
+				if err := writeJSON(os.Stdout, newSARIFLog(opt.Src, cfg, results)); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unsupported output: %s", opt.Output)
+			}
+
+			errs := 0
+			for _, r := range results {
+				if r.Severity == swag.SeverityError {
+					errs++
+				}
+			}
+			if errs > 0 {
+				return fmt.Errorf("%d lint errors found", errs)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opt.Src, "src", "", "Specify the swagger configuration file path or url")
+	cmd.Flags().StringVar(&opt.Config, "config", "", "Specify the yaml config file of the lint rules")
+	cmd.Flags().StringVar(&opt.Output, "output", OutputText, "Specify the output format, supports text, json, sarif")
+	cmd.Flags().BoolVar(&opt.RemoteRefs, "remote-refs", false, "Specify whether the http(s) $ref to other documents are resolved")
+	return cmd
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// sarifLog is the Static Analysis Results Interchange Format (SARIF) 2.1.0 log,
+// which is consumed by the code scanning tools.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	DefaultConfiguration sarifRuleDefaults `json:"defaultConfiguration"`
+}
+
+type sarifRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+func newSARIFLog(src string, cfg *swag.LintConfig, results []swag.LintResult) *sarifLog {
+	driver := sarifDriver{
+		Name:           "apidoc",
+		InformationURI: "https://github.com/zc2638/apidoc",
+	}
+	for _, rule := range swag.LintRules() {
+		severity := rule.Severity
+		if cfg != nil {
+			if s, ok := cfg.Rules[rule.Name]; ok {
+				severity = s
+			}
+		}
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.Name,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifRuleDefaults{Level: sarifLevel(severity)},
+		})
+	}
+
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: []sarifResult{}}
+	for _, r := range results {
+		location := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: src}},
+			LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: r.Pointer}},
+		}
+		if r.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: r.Line}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    r.Rule,
+			Level:     sarifLevel(r.Severity),
+			Message:   sarifMessage{Text: r.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+	return &sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+func sarifLevel(severity swag.Severity) string {
+	switch severity {
+	case swag.SeverityError:
+		return "error"
+	case swag.SeverityWarning:
+		return "warning"
+	case swag.SeverityOff:
+		return "none"
+	}
+	return "note"
+}
@@ -0,0 +1,103 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+func TestSARIFRules(t *testing.T) {
+	cfg := &swag.LintConfig{Rules: map[string]swag.Severity{
+		"operation-summary": swag.SeverityOff,
+		"path-kebab-case":   swag.SeverityInfo,
+	}}
+	log := newSARIFLog("swagger.yaml", cfg, nil)
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version %s with %d runs, want 2.1.0 with 1 run", log.Version, len(log.Runs))
+	}
+
+	levels := make(map[string]string)
+	for _, rule := range log.Runs[0].Tool.Driver.Rules {
+		levels[rule.ID] = rule.DefaultConfiguration.Level
+	}
+	if len(levels) != len(swag.LintRules()) {
+		t.Errorf("%d rules, want %d", len(levels), len(swag.LintRules()))
+	}
+	for id, want := range map[string]string{
+		"operation-summary":      "none",
+		"path-kebab-case":        "note",
+		"operation-operation-id": "error",
+		"description-non-empty":  "warning",
+	} {
+		if levels[id] != want {
+			t.Errorf("rule %s level = %s, want %s", id, levels[id], want)
+		}
+	}
+}
+
+func TestSARIFResults(t *testing.T) {
+	log := newSARIFLog("swagger.yaml", nil, []swag.LintResult{
+		{Rule: "path-kebab-case", Severity: swag.SeverityInfo, Pointer: "/paths/~1petStore", Line: 6, Message: "not kebab-case"},
+		{Rule: "operation-operation-id", Severity: swag.SeverityError, Pointer: "/paths/~1pets/get", Message: "no operationId"},
+	})
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	first := results[0]
+	if first.RuleID != "path-kebab-case" || first.Level != "note" {
+		t.Errorf("first result is %s at %s, want path-kebab-case at note", first.RuleID, first.Level)
+	}
+	loc := first.Locations[0]
+	if loc.PhysicalLocation.ArtifactLocation.URI != "swagger.yaml" {
+		t.Errorf("artifact = %s, want swagger.yaml", loc.PhysicalLocation.ArtifactLocation.URI)
+	}
+	if loc.PhysicalLocation.Region == nil || loc.PhysicalLocation.Region.StartLine != 6 {
+		t.Errorf("region = %+v, want line 6", loc.PhysicalLocation.Region)
+	}
+	if loc.LogicalLocations[0].FullyQualifiedName != "/paths/~1petStore" {
+		t.Errorf("logical location = %s, want the pointer", loc.LogicalLocations[0].FullyQualifiedName)
+	}
+
+	// the region is left out when the line is unknown
+	second := results[1]
+	if second.Level != "error" || second.Locations[0].PhysicalLocation.Region != nil {
+		t.Errorf("second result is %s with region %+v, want error without a region", second.Level, second.Locations[0].PhysicalLocation.Region)
+	}
+}
+
+func TestSARIFEmptyResults(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, newSARIFLog("swagger.yaml", nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Runs []struct {
+			Results json.RawMessage `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	// the code scanning uploads reject a null results
+	if len(log.Runs) != 1 || string(log.Runs[0].Results) != "[]" {
+		t.Errorf("runs = %s, want one run with empty results", buf.String())
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+// merge combines the srcs into one document, each src is a service named by its base name.
+func merge(opt *Option, srcs []string) error {
+	mopt := *opt
+	mopt.batch = len(srcs) > 1
+
+	services := make([]swag.Service, 0, len(srcs))
+	names := make(map[string]struct{}, len(srcs))
+	for _, src := range srcs {
+		api, err := load(&mopt, src)
+		if err != nil {
+			return err
+		}
+		name := outputName(src, "")
+		names[name] = struct{}{}
+		services = append(services, swag.Service{Name: name, Prefix: opt.Prefixes[name], API: api})
+	}
+	for name := range opt.Prefixes {
+		if _, ok := names[name]; !ok {
+			return fmt.Errorf("prefix of unknown service: %s", name)
+		}
+	}
+	api, err := swag.Merge(swag.MergeOption{
+		Info:           swag.Info{Title: opt.Title},
+		GroupByService: opt.GroupByService,
+	}, services...)
+	if err != nil {
+		return err
+	}
+	_, err = output(opt, api, opt.Merge)
+	return err
+}
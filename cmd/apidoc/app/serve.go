@@ -0,0 +1,100 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zc2638/apidoc"
+)
+
+type ServeOption struct {
+	Addr        string
+	Template    string
+	Font        string
+	CacheSize   int
+	CacheTTL    time.Duration
+	MaxBodySize int64
+	AllowURL    bool
+	URLTimeout  time.Duration
+
+	MaxConcurrency int
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+}
+
+func NewServeCommand() *cobra.Command {
+	opt := &ServeOption{}
+	cmd := &cobra.Command{
+		Use:          "serve",
+		Short:        "Start a http server rendering the specs on demand",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			srv := &http.Server{
+				Addr: opt.Addr,
+				Handler: apidoc.NewServer(apidoc.ServerOption{
+					Template:    opt.Template,
+					FontFile:    opt.Font,
+					CacheSize:   opt.CacheSize,
+					CacheTTL:    opt.CacheTTL,
+					MaxBodySize: opt.MaxBodySize,
+					AllowURL:    opt.AllowURL,
+					URLTimeout:  opt.URLTimeout,
+
+					MaxConcurrency: opt.MaxConcurrency,
+				}),
+				ReadHeaderTimeout: 10 * time.Second,
+				ReadTimeout:       opt.ReadTimeout,
+				WriteTimeout:      opt.WriteTimeout,
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				_ = srv.Shutdown(shutdownCtx)
+			}()
+
+			fmt.Fprintln(os.Stderr, "listening on", opt.Addr)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opt.Addr, "addr", ":8080", "Specify the listen address")
+	cmd.Flags().StringVar(&opt.Template, "template", apidoc.DefaultTemplate, "Specify the template file or directory used when the request does not choose a built-in template")
+	cmd.Flags().StringVar(&opt.Font, "font", "", "Specify the TrueType font file embedded by the native pdf engine")
+	cmd.Flags().IntVar(&opt.CacheSize, "cache-size", 100, "Specify the max number of rendered results kept in memory, 0 disables the cache")
+	cmd.Flags().DurationVar(&opt.CacheTTL, "cache-ttl", time.Hour, "Specify the lifetime of a cached result, 0 means never expire")
+	cmd.Flags().Int64Var(&opt.MaxBodySize, "max-body-size", apidoc.DefaultMaxBodySize, "Specify the max size in bytes of the uploaded or fetched spec")
+	cmd.Flags().BoolVar(&opt.AllowURL, "allow-url", false, "Specify whether the spec can be fetched from the url query parameter, only enable it for trusted clients")
+	cmd.Flags().DurationVar(&opt.URLTimeout, "url-timeout", apidoc.DefaultURLTimeout, "Specify the timeout of fetching the spec from the url query parameter")
+	cmd.Flags().IntVar(&opt.MaxConcurrency, "max-concurrency", 0, "Specify the max number of specs rendered at the same time, 0 means the number of CPUs")
+	cmd.Flags().DurationVar(&opt.ReadTimeout, "read-timeout", time.Minute, "Specify the timeout of reading a request including the uploaded spec")
+	cmd.Flags().DurationVar(&opt.WriteTimeout, "write-timeout", 5*time.Minute, "Specify the timeout of handling a request, from the end of its headers to the end of the rendered response")
+	return cmd
+}
@@ -0,0 +1,78 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zc2638/apidoc"
+)
+
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+type ValidateOption struct {
+	Src    string
+	Output string
+
+	RemoteRefs bool // allows the http(s) references to other documents.
+}
+
+func NewValidateCommand() *cobra.Command {
+	opt := &ValidateOption{}
+	cmd := &cobra.Command{
+		Use:          "validate",
+		Short:        "Validate the spec against the Swagger 2.0 rules, exit non-zero if any problem exists",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opt.Src == "" {
+				return fmt.Errorf("src is required")
+			}
+			problems, err := apidoc.ValidateSource(opt.Src, apidoc.LoadOption{RemoteRefs: opt.RemoteRefs})
+			if err != nil {
+				return err
+			}
+
+			switch opt.Output {
+			case OutputText:
+				for _, p := range problems {
+					fmt.Printf("%s: %s\n", opt.Src, p)
+				}
+			case OutputJSON:
+				if problems == nil {
+					problems = []apidoc.Problem{}
+				}
+				if err := writeJSON(os.Stdout, problems); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unsupported output: %s", opt.Output)
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("%d problems found", len(problems))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opt.Src, "src", "", "Specify the swagger configuration file path or url")
+	cmd.Flags().StringVar(&opt.Output, "output", OutputText, "Specify the output format, supports text, json")
+	cmd.Flags().BoolVar(&opt.RemoteRefs, "remote-refs", false, "Specify whether the http(s) $ref to other documents are resolved")
+	return cmd
+}
@@ -0,0 +1,229 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/zc2638/apidoc"
+)
+
+// watchInterval is the interval of polling the watched files.
+const watchInterval = 200 * time.Millisecond
+
+const liveReloadVersionPath = "/__apidoc/version"
+
+// watch renders the src, and re-renders it whenever the src, the documents it refers to or the template files change.
+// The html result is also served with live reload if the watch address is set.
+func watch(ctx context.Context, opt *Option, src string) error {
+	if opt.IsData {
+		return fmt.Errorf("data mode is not supported in watch mode")
+	}
+	if apidoc.IsURL(src) {
+		return fmt.Errorf("watch mode requires a local src file")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var lr *liveReload
+	if opt.Format == FormatHTML && opt.WatchAddr != "" {
+		lr = &liveReload{}
+		srv := &http.Server{Addr: opt.WatchAddr, Handler: lr}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintln(os.Stderr, "error:", err)
+			}
+		}()
+		defer srv.Close()
+		fmt.Fprintf(os.Stderr, "serving on http://%s\n", opt.WatchAddr)
+	}
+
+	// the referred documents are listed again on each build, since the references may change.
+	var sources []string
+	build := func() {
+		sources, _ = apidoc.Sources(src, apidoc.LoadOption{RemoteRefs: opt.RemoteRefs})
+		data, err := generate(opt, src)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			if lr != nil {
+				lr.fail(err)
+			}
+			return
+		}
+		fmt.Fprintf(os.Stderr, "rendered %s at %s\n", src, time.Now().Format("15:04:05"))
+		if lr != nil {
+			lr.update(data)
+		}
+	}
+	build()
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	last := snapshot(watchFiles(opt, sources))
+	var changed time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		// rapid saves keep resetting the change time,
+		// so only the final state is rendered after a quiet period.
+		current := snapshot(watchFiles(opt, sources))
+		if !sameSnapshot(last, current) {
+			last = current
+			changed = time.Now()
+			continue
+		}
+		if !changed.IsZero() && time.Since(changed) >= opt.WatchDebounce {
+			changed = time.Time{}
+			build()
+			last = snapshot(watchFiles(opt, sources))
+		}
+	}
+}
+
+// watchFiles returns the local documents and the custom template files.
+func watchFiles(opt *Option, sources []string) []string {
+	files := make([]string, 0, len(sources))
+	for _, source := range sources {
+		if !apidoc.IsURL(source) {
+			files = append(files, source)
+		}
+	}
+	if apidoc.IsBuiltinTemplate(opt.Template) {
+		return files
+	}
+	info, err := os.Stat(opt.Template)
+	if err != nil {
+		return files
+	}
+	if !info.IsDir() {
+		return append(files, opt.Template)
+	}
+	matches, _ := filepath.Glob(filepath.Join(opt.Template, "*.html"))
+	return append(files, matches...)
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshot returns the states of the existing files.
+func snapshot(files []string) map[string]fileState {
+	states := make(map[string]fileState, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		states[file] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	return states
+}
+
+func sameSnapshot(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for file, state := range a {
+		other, ok := b[file]
+		if !ok || !state.modTime.Equal(other.modTime) || state.size != other.size {
+			return false
+		}
+	}
+	return true
+}
+
+// liveReload serves the latest rendered page,
+// the page polls the version and reloads itself once it is re-rendered.
+type liveReload struct {
+	mu      sync.RWMutex
+	version int
+	page    []byte
+}
+
+func (lr *liveReload) update(page []byte) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.version++
+	lr.page = page
+}
+
+func (lr *liveReload) fail(err error) {
+	lr.update([]byte("<!DOCTYPE html><html><body><h1>Render failed</h1><pre>" +
+		html.EscapeString(err.Error()) + "</pre></body></html>"))
+}
+
+func (lr *liveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lr.mu.RLock()
+	version, page := lr.version, lr.page
+	lr.mu.RUnlock()
+
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Path == liveReloadVersionPath {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(strconv.Itoa(version)))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(injectLiveReload(page, version))
+}
+
+// injectLiveReload inserts the script polling the version before the end of the body.
+func injectLiveReload(page []byte, version int) []byte {
+	script := fmt.Sprintf(`<script>
+(function () {
+    setInterval(function () {
+        fetch(%q).then(function (res) {
+            return res.text();
+        }).then(function (version) {
+            if (version !== %q) {
+                location.reload();
+            }
+        }).catch(function () {
+        });
+    }, 1000);
+})();
+</script>
+`, liveReloadVersionPath, strconv.Itoa(version))
+
+	i := bytes.LastIndex(page, []byte("</body>"))
+	if i < 0 {
+		return append(page[:len(page):len(page)], script...)
+	}
+	out := make([]byte, 0, len(page)+len(script))
+	out = append(out, page[:i]...)
+	out = append(out, script...)
+	return append(out, page[i:]...)
+}
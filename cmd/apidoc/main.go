@@ -15,6 +15,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 	_ "time/tzdata"
 
@@ -24,6 +25,10 @@ import (
 func main() {
 	command := app.NewServerCommand()
 	if err := command.Execute(); err != nil {
+		var exitErr *app.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
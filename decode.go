@@ -0,0 +1,262 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	dittoJson "github.com/99nil/ditto/json"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// decodeDocument decodes the json or yaml content into generic values,
+// the numbers are kept as json.Number with their source texts.
+func decodeDocument(content []byte) (interface{}, error) {
+	return decodeDocumentAliases(content, maxAliasNodes)
+}
+
+// decodeDocumentAliases is decodeDocument expanding at most aliasNodes nodes from the yaml aliases.
+func decodeDocumentAliases(content []byte, aliasNodes int) (interface{}, error) {
+	line, pos, ok := dittoJson.CheckBytes(content)
+	if ok {
+		var doc interface{}
+		dec := json.NewDecoder(bytes.NewReader(content))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("json parse failed: %v", err)
+		}
+		return doc, nil
+	}
+	doc, err := decodeYAML(content, aliasNodes)
+	if err != nil {
+		return nil, fmt.Errorf("\njson check failed, line: %d, pos: %d\nyaml parse failed: %v", line, pos, err)
+	}
+	return doc, nil
+}
+
+// decodeYAML decodes the yaml content into generic values which can be encoded as json,
+// at most aliasNodes nodes are expanded from the aliases.
+func decodeYAML(content []byte, aliasNodes int) (interface{}, error) {
+	var node yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &node); err != nil {
+		return nil, err
+	}
+	d := &yamlDecoder{aliasNodes: aliasNodes, expanding: make(map[*yamlv3.Node]struct{})}
+	return d.value(&node)
+}
+
+const (
+	// maxAliasNodes caps the nodes expanded from the aliases of a document by default.
+	maxAliasNodes = 1 << 20
+
+	// the range of the decoded nodes where the allowed alias ratio drops, as yaml.v2 and yaml.v3 do.
+	aliasRatioRangeLow  = 400000
+	aliasRatioRangeHigh = 4000000
+)
+
+var errExcessiveAliasing = fmt.Errorf("document contains excessive aliasing")
+
+// allowedAliasRatio returns the allowed ratio of the nodes expanded from the aliases
+// to all the decoded nodes, which drops from 0.99 to 0.10 as the document grows.
+func allowedAliasRatio(decoded int) float64 {
+	switch {
+	case decoded <= aliasRatioRangeLow:
+		return 0.99
+	case decoded >= aliasRatioRangeHigh:
+		return 0.10
+	default:
+		return 0.99 - 0.89*(float64(decoded-aliasRatioRangeLow)/(aliasRatioRangeHigh-aliasRatioRangeLow))
+	}
+}
+
+// yamlDecoder converts the yaml nodes into generic values,
+// the aliases are expanded with a check of the cycles and the limits of yaml.v2 against the alias bombs.
+type yamlDecoder struct {
+	aliasNodes int                       // max nodes decoded inside the aliases.
+	expanding  map[*yamlv3.Node]struct{} // anchors being expanded.
+	decoded    int                       // nodes decoded.
+	aliased    int                       // nodes decoded inside an alias.
+}
+
+// yaml11Bools are the scalars resolved to booleans by YAML 1.1 as yaml.v2 did,
+// they are kept as strings and converted only for the boolean fields.
+var yaml11Bools = map[string]bool{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true, "on": true, "On": true, "ON": true,
+	"n": false, "N": false, "no": false, "No": false, "NO": false, "off": false, "Off": false, "OFF": false,
+}
+
+func (d *yamlDecoder) value(n *yamlv3.Node) (interface{}, error) {
+	d.decoded++
+	if len(d.expanding) > 0 {
+		d.aliased++
+		if d.aliased > d.aliasNodes ||
+			d.aliased > 100 && d.decoded > 1000 && float64(d.aliased)/float64(d.decoded) > allowedAliasRatio(d.decoded) {
+			return nil, errExcessiveAliasing
+		}
+	}
+
+	switch n.Kind {
+	case 0:
+		return nil, nil
+	case yamlv3.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return d.value(n.Content[0])
+	case yamlv3.AliasNode:
+		if _, ok := d.expanding[n.Alias]; ok {
+			return nil, fmt.Errorf("line %d: anchor '%s' value contains itself", n.Line, n.Value)
+		}
+		d.expanding[n.Alias] = struct{}{}
+		defer delete(d.expanding, n.Alias)
+		return d.value(n.Alias)
+	case yamlv3.SequenceNode:
+		s := make([]interface{}, 0, len(n.Content))
+		for _, item := range n.Content {
+			v, err := d.value(item)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+		}
+		return s, nil
+	case yamlv3.MappingNode:
+		m := make(map[string]interface{}, len(n.Content)/2)
+		var merges []*yamlv3.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.ShortTag() == "!!merge" {
+				merges = append(merges, value)
+				continue
+			}
+			v, err := d.value(value)
+			if err != nil {
+				return nil, err
+			}
+			m[key.Value] = v
+		}
+		// the merged keys never override the keys of the mapping
+		for _, merge := range merges {
+			v, err := d.value(merge)
+			if err != nil {
+				return nil, err
+			}
+			items, ok := v.([]interface{})
+			if !ok {
+				items = []interface{}{v}
+			}
+			for _, item := range items {
+				mm, _ := item.(map[string]interface{})
+				for k, v := range mm {
+					if _, ok := m[k]; !ok {
+						m[k] = v
+					}
+				}
+			}
+		}
+		return m, nil
+	}
+
+	switch n.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!str", "!!timestamp", "!!binary":
+		return n.Value, nil
+	case "!!int", "!!float":
+		if json.Valid([]byte(n.Value)) {
+			return json.Number(n.Value), nil
+		}
+	}
+	var v interface{}
+	if err := n.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// coerceScalars converts the numbers and booleans decoded for the string fields of the type t to strings,
+// and the YAML 1.1 booleans decoded for the boolean fields to booleans,
+// as yaml decoded `version: 1.0` or `required: yes` into the fields before the documents were converted to json.
+func coerceScalars(v interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if s, ok := v.(string); ok {
+			if b, ok := yaml11Bools[s]; ok {
+				return b
+			}
+		}
+	case reflect.String:
+		switch val := v.(type) {
+		case json.Number:
+			return string(val)
+		case bool, int, int64, uint64, float64:
+			return fmt.Sprint(val)
+		}
+	case reflect.Slice, reflect.Array:
+		if s, ok := v.([]interface{}); ok {
+			for i, item := range s {
+				s[i] = coerceScalars(item, t.Elem())
+			}
+		}
+	case reflect.Map:
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, item := range m {
+				m[k] = coerceScalars(item, t.Elem())
+			}
+		}
+	case reflect.Struct:
+		if m, ok := v.(map[string]interface{}); ok {
+			coerceFields(m, t)
+		}
+	}
+	return v
+}
+
+// coerceFields coerces the values of the object m decoded for the fields of the struct type t.
+func coerceFields(m map[string]interface{}, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				coerceFields(m, ft)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if item, ok := m[name]; ok {
+			m[name] = coerceScalars(item, field.Type)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// aliasBomb returns a document whose aliases expand to 9^levels strings.
+func aliasBomb(levels int) string {
+	var b strings.Builder
+	b.WriteString("swagger: \"2.0\"\ninfo: {title: bomb, version: \"1\"}\npaths: {}\nx-l0: &l0 [lol, lol, lol, lol, lol, lol, lol, lol, lol]\n")
+	for i := 1; i <= levels; i++ {
+		ref := fmt.Sprintf("*l%d", i-1)
+		fmt.Fprintf(&b, "x-l%d: &l%d [%s]\n", i, i, strings.TrimSuffix(strings.Repeat(ref+", ", 9), ", "))
+	}
+	return b.String()
+}
+
+func TestDecodeAliases(t *testing.T) {
+	doc, err := decodeDocument([]byte(`
+base: &base {type: string, format: uuid}
+id: *base
+name:
+  <<: *base
+  format: name
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"base": map[string]interface{}{"type": "string", "format": "uuid"},
+		"id":   map[string]interface{}{"type": "string", "format": "uuid"},
+		"name": map[string]interface{}{"type": "string", "format": "name"},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("doc = %#v, want %#v", doc, want)
+	}
+}
+
+func TestDecodeRecursiveAlias(t *testing.T) {
+	for _, content := range []string{
+		"x-a: &a {b: *a}",
+		"x-a: &a [1, *a]",
+		"x-a: &a {<<: *a}",
+	} {
+		_, err := Load([]byte("swagger: \"2.0\"\n" + content))
+		if err == nil || !strings.Contains(err.Error(), "anchor 'a' value contains itself") {
+			t.Errorf("%s: error = %v, want the recursive anchor", content, err)
+		}
+	}
+}
+
+func TestDecodeAliasBomb(t *testing.T) {
+	content := []byte(aliasBomb(9))
+	for name, decode := range map[string]func() error{
+		"load": func() error {
+			_, err := Load(content)
+			return err
+		},
+		"validate": func() error {
+			_, err := Validate(content)
+			return err
+		},
+	} {
+		if err := decode(); err == nil || !strings.Contains(err.Error(), errExcessiveAliasing.Error()) {
+			t.Errorf("%s: error = %v, want %v", name, err, errExcessiveAliasing)
+		}
+	}
+}
@@ -17,7 +17,7 @@ package apidoc
 import (
 	"encoding/json"
 	"html/template"
-	"net/http"
+	"sort"
 
 	"github.com/russross/blackfriday/v2"
 
@@ -43,44 +43,11 @@ func checkTag(e *swag.Endpoint, tag string) bool {
 }
 
 func getEndpointSet(es *swag.Endpoints) []*swag.Endpoint {
-	set := make([]*swag.Endpoint, 0, 9)
-	if es.Get != nil {
-		es.Get.Method = http.MethodGet
-		set = append(set, es.Get)
-	}
-	if es.Post != nil {
-		es.Post.Method = http.MethodPost
-		set = append(set, es.Post)
-	}
-	if es.Delete != nil {
-		es.Delete.Method = http.MethodDelete
-		set = append(set, es.Delete)
-	}
-	if es.Put != nil {
-		es.Put.Method = http.MethodPut
-		set = append(set, es.Put)
-	}
-	if es.Patch != nil {
-		es.Patch.Method = http.MethodPatch
-		set = append(set, es.Patch)
-	}
-	if es.Options != nil {
-		es.Options.Method = http.MethodOptions
-		set = append(set, es.Options)
-	}
-	if es.Head != nil {
-		es.Head.Method = http.MethodHead
-		set = append(set, es.Head)
-	}
-	if es.Connect != nil {
-		es.Connect.Method = http.MethodConnect
-		set = append(set, es.Connect)
-	}
-	if es.Trace != nil {
-		es.Trace.Method = http.MethodTrace
-		set = append(set, es.Trace)
-	}
-	return set
+	return es.All()
+}
+
+func getTagGroups(api *swag.API) []swag.TagGroup {
+	return api.TagGroups()
 }
 
 func getParameters(e *swag.Endpoint) []swag.Parameter {
@@ -125,16 +92,121 @@ func getResponse(api *swag.API, res *swag.Response) string {
 	return string(data)
 }
 
+// example represents a json example of a body, the label is set for oneOf/anyOf variants.
+type example struct {
+	Label string
+	Body  string
+}
+
+func getExamples(api *swag.API, schema *swag.Schema) []example {
+	if schema == nil {
+		return nil
+	}
+	variants := api.GetVariants(schema)
+	if len(variants) == 0 {
+		variants = []swag.Variant{{Schema: schema}}
+	}
+
+	examples := make([]example, 0, len(variants))
+	for _, v := range variants {
+		data, err := json.MarshalIndent(api.GetObject(v.Schema), "", "    ")
+		if err != nil {
+			continue
+		}
+		examples = append(examples, example{Label: v.Label, Body: string(data)})
+	}
+	return examples
+}
+
+func getBodyExamples(api *swag.API, e *swag.Endpoint) []example {
+	for _, p := range e.Parameters {
+		if p.In != "body" {
+			continue
+		}
+		return getContentExamples(api, p.Schema, p.Contents)
+	}
+	return nil
+}
+
+func getResponseExamples(api *swag.API, res *swag.Response) []example {
+	return getContentExamples(api, res.Schema, res.Contents)
+}
+
+// getContentExamples returns the examples of each media type if the media types have different schemas,
+// the examples are labeled with the media type.
+func getContentExamples(api *swag.API, schema *swag.Schema, contents []swag.MediaContent) []example {
+	if len(contents) == 0 {
+		return getExamples(api, schema)
+	}
+	var examples []example
+	for _, c := range contents {
+		for _, v := range getExamples(api, c.Schema) {
+			v.Label = contentLabel(c.MediaType, v.Label)
+			examples = append(examples, v)
+		}
+	}
+	return examples
+}
+
 func getBodyRows(api *swag.API, e *swag.Endpoint) []swag.Row {
 	for _, p := range e.Parameters {
 		if p.In != "body" {
 			continue
 		}
-		return api.GetRows(p.Schema)
+		return getContentRows(api, p.Schema, p.Contents)
 	}
 	return nil
 }
 
 func getResponseRows(api *swag.API, res *swag.Response) []swag.Row {
-	return api.GetRows(res.Schema)
+	return getContentRows(api, res.Schema, res.Contents)
+}
+
+// getContentRows returns the rows of each media type if the media types have different schemas,
+// the rows are prefixed and labeled with the media type.
+func getContentRows(api *swag.API, schema *swag.Schema, contents []swag.MediaContent) []swag.Row {
+	if len(contents) == 0 {
+		return api.GetRows(schema)
+	}
+	var rows []swag.Row
+	for _, c := range contents {
+		for _, row := range api.GetRows(c.Schema) {
+			row.Name = "(" + c.MediaType + ")." + row.Name
+			row.Variant = contentLabel(c.MediaType, row.Variant)
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func contentLabel(mediaType, label string) string {
+	if label == "" {
+		return mediaType
+	}
+	return mediaType + ", " + label
+}
+
+func sortedResponseCodes(responses map[string]*swag.Response) []string {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func sortedHeaderNames(headers map[string]swag.Header) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "True"
+	}
+	return "False"
 }
@@ -0,0 +1,123 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// variantsSpec has a oneOf request body, an anyOf response and a response with a media type per schema.
+const variantsSpec = `openapi: 3.0.3
+info: {title: shapes, version: "1.0"}
+paths:
+  /shapes:
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                kind: {type: string, example: shape}
+              oneOf:
+              - $ref: "#/components/schemas/Circle"
+              - title: Square
+                type: object
+                properties:
+                  side: {type: number, example: 2}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                anyOf:
+                - $ref: "#/components/schemas/Circle"
+                - type: object
+                  properties:
+                    color: {type: string, example: red}
+        "201":
+          description: created
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Circle"}
+            application/xml:
+              schema:
+                oneOf:
+                - $ref: "#/components/schemas/Circle"
+components:
+  schemas:
+    Circle:
+      type: object
+      properties:
+        radius: {type: number, example: 1.5}
+`
+
+func TestVariantExamples(t *testing.T) {
+	api, err := Load([]byte(variantsSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := api.Paths["/shapes"].Post
+	for _, c := range []struct {
+		name string
+		got  []example
+		want []example
+	}{
+		{
+			// each alternative is combined with the rest of the schema
+			name: "body",
+			got:  getBodyExamples(api, e),
+			want: []example{
+				{Label: "oneOf Circle", Body: "{\n    \"kind\": \"shape\",\n    \"radius\": 1.5\n}"},
+				{Label: "oneOf Square", Body: "{\n    \"kind\": \"shape\",\n    \"side\": 2\n}"},
+			},
+		},
+		{
+			name: "response",
+			got:  getResponseExamples(api, e.Responses["200"]),
+			want: []example{
+				{Label: "anyOf Circle", Body: "{\n    \"radius\": 1.5\n}"},
+				{Label: "anyOf 2", Body: "{\n    \"color\": \"red\"\n}"},
+			},
+		},
+		{
+			name: "media types",
+			got:  getResponseExamples(api, e.Responses["201"]),
+			want: []example{
+				{Label: "application/json", Body: "{\n    \"radius\": 1.5\n}"},
+				{Label: "application/xml, oneOf Circle", Body: "{\n    \"radius\": 1.5\n}"},
+			},
+		},
+	} {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s examples = %+v, want %+v", c.name, c.got, c.want)
+		}
+	}
+
+	// the default template labels the examples of the alternatives
+	data, err := Render(api, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, label := range []string{"oneOf Circle", "oneOf Square", "anyOf Circle", "anyOf 2", "application/xml, oneOf Circle"} {
+		if want := `<p class="variant">` + label + `</p>`; !strings.Contains(string(data), want) {
+			t.Errorf("the default template does not contain %s", want)
+		}
+	}
+}
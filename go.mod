@@ -1,13 +1,15 @@
 module github.com/zc2638/apidoc
 
-go 1.17
+go 1.21
 
 require (
+	codeberg.org/go-pdf/fpdf v0.11.1
 	github.com/99nil/ditto v0.0.0-20210721070836-b525d5dadba2
 	github.com/SebastiaanKlippert/go-wkhtmltopdf v1.7.2
 	github.com/russross/blackfriday/v2 v2.1.0
 	github.com/spf13/cobra v1.4.0
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
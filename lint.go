@@ -0,0 +1,98 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+// Lint checks the json or yaml content against the lint rules configured by cfg,
+// the results are sorted by their source lines.
+func Lint(content []byte, cfg *swag.LintConfig) ([]swag.LintResult, error) {
+	api, err := Load(content)
+	if err != nil {
+		return nil, err
+	}
+	return lint(content, api, cfg), nil
+}
+
+// LintSource reads the src like LoadSource and lints it like Lint,
+// the results in the bundled schemas are reported at the lines of the definitions.
+func LintSource(src string, cfg *swag.LintConfig, opt LoadOption) ([]swag.LintResult, error) {
+	content, err := ReadSource(src)
+	if err != nil {
+		return nil, err
+	}
+	bundled, err := Bundle(content, src, opt)
+	if err != nil {
+		return nil, err
+	}
+	api, err := Load(bundled)
+	if err != nil {
+		return nil, err
+	}
+	return lint(content, api, cfg), nil
+}
+
+// lint checks the api loaded from the content, and maps the results to the lines of the content.
+func lint(content []byte, api *swag.API, cfg *swag.LintConfig) []swag.LintResult {
+	results := swag.Lint(api, cfg)
+
+	lines := sourceLines(content)
+	_, openAPI := lines["/openapi"]
+	for i := range results {
+		pointer := results[i].Pointer
+		if name := strings.TrimPrefix(pointer, "/definitions/"); name != pointer {
+			pointer = definitionPointer(unescapePointer(name), openAPI)
+		}
+		results[i].Line = lineOf(lines, pointer)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Line < results[j].Line
+	})
+	return results
+}
+
+// definitionPointer returns the pointer of the definition in the source.
+// The $defs hoisted from a schema are named as their pointers, e.g. `Pet/$defs/Name`,
+// the definitions of OpenAPI 3.x are converted from the component schemas,
+// or hoisted from the inline schemas, e.g. `paths/~1pets/post/requestBody/content/application~1json/schema/$defs/Name`.
+func definitionPointer(name string, openAPI bool) string {
+	if !openAPI {
+		return "/definitions/" + name
+	}
+	if strings.Contains(name, "/schema/$defs/") && (strings.HasPrefix(name, "paths/") || strings.HasPrefix(name, "components/")) {
+		return "/" + name
+	}
+	return "/components/schemas/" + name
+}
+
+// LoadLintConfig decodes the yaml lint config and checks its rules.
+func LoadLintConfig(content []byte) (*swag.LintConfig, error) {
+	cfg := new(swag.LintConfig)
+	if err := yaml.UnmarshalStrict(content, cfg); err != nil {
+		return nil, fmt.Errorf("lint config parse failed: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,182 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"testing"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+const lintSwagger = `swagger: "2.0"
+info: {title: t, version: "1"}
+tags:
+  - name: pet
+paths:
+  /petStore:
+    get:
+      tags: [pet]
+      responses:
+        "404":
+          description: missing
+definitions:
+  Pet:
+    type: object
+`
+
+const lintOpenAPI = `openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /pets:
+    get:
+      summary: list
+      operationId: listPets
+      responses:
+        "200":
+          description: ok
+components:
+  schemas:
+    Pet:
+      type: object
+`
+
+// compareLint checks the rule, severity, pointer and line of the results in order.
+func compareLint(t *testing.T, got, want []swag.LintResult) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d results %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Rule != w.Rule || g.Severity != w.Severity || g.Pointer != w.Pointer || g.Line != w.Line {
+			t.Errorf("result %d = %s %s %s:%d, want %s %s %s:%d",
+				i, g.Rule, g.Severity, g.Pointer, g.Line, w.Rule, w.Severity, w.Pointer, w.Line)
+		}
+		if g.Message == "" {
+			t.Errorf("result %d of rule %s has no message", i, g.Rule)
+		}
+	}
+}
+
+func TestLintDefaultRules(t *testing.T) {
+	results, err := Lint([]byte(lintSwagger), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareLint(t, results, []swag.LintResult{
+		{Rule: "description-non-empty", Severity: swag.SeverityWarning, Pointer: "/tags/0", Line: 4},
+		{Rule: "path-kebab-case", Severity: swag.SeverityWarning, Pointer: "/paths/~1petStore", Line: 6},
+		{Rule: "operation-summary", Severity: swag.SeverityError, Pointer: "/paths/~1petStore/get", Line: 7},
+		{Rule: "operation-operation-id", Severity: swag.SeverityError, Pointer: "/paths/~1petStore/get", Line: 7},
+		{Rule: "error-response-schema", Severity: swag.SeverityError, Pointer: "/paths/~1petStore/get/responses/404", Line: 10},
+		{Rule: "description-non-empty", Severity: swag.SeverityWarning, Pointer: "/definitions/Pet", Line: 13},
+	})
+}
+
+func TestLintConfiguredRules(t *testing.T) {
+	cfg := &swag.LintConfig{Rules: map[string]swag.Severity{
+		"operation-summary":     swag.SeverityOff,
+		"path-kebab-case":       swag.SeverityOff,
+		"description-non-empty": swag.SeverityError,
+	}}
+	results, err := Lint([]byte(lintSwagger), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareLint(t, results, []swag.LintResult{
+		{Rule: "description-non-empty", Severity: swag.SeverityError, Pointer: "/tags/0", Line: 4},
+		{Rule: "operation-operation-id", Severity: swag.SeverityError, Pointer: "/paths/~1petStore/get", Line: 7},
+		{Rule: "error-response-schema", Severity: swag.SeverityError, Pointer: "/paths/~1petStore/get/responses/404", Line: 10},
+		{Rule: "description-non-empty", Severity: swag.SeverityError, Pointer: "/definitions/Pet", Line: 13},
+	})
+}
+
+func TestLintOpenAPILines(t *testing.T) {
+	results, err := Lint([]byte(lintOpenAPI), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pointers stay on the converted swagger document, the lines come from the component schemas
+	compareLint(t, results, []swag.LintResult{
+		{Rule: "description-non-empty", Severity: swag.SeverityWarning, Pointer: "/definitions/Pet", Line: 13},
+	})
+}
+
+func TestLoadLintConfig(t *testing.T) {
+	cfg, err := LoadLintConfig([]byte("rules:\n  operation-summary: off\nerrorDefinition: Problem\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Rules["operation-summary"] != swag.SeverityOff || cfg.ErrorDefinition != "Problem" {
+		t.Errorf("config = %+v, want operation-summary off and the Problem definition", cfg)
+	}
+
+	for _, content := range []string{
+		"rules:\n  no-such-rule: error\n",
+		"rules:\n  operation-summary: fatal\n",
+		"rule:\n  operation-summary: off\n",
+	} {
+		if _, err := LoadLintConfig([]byte(content)); err == nil {
+			t.Errorf("%q is loaded without an error", content)
+		}
+	}
+}
+
+func TestLintHoistedDefinitions(t *testing.T) {
+	content := `openapi: 3.1.0
+info: {title: t, version: "1"}
+paths:
+  /pets:
+    post:
+      summary: add
+      operationId: addPet
+      requestBody:
+        description: pet
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name: {$ref: "#/$defs/Name"}
+              $defs:
+                Name:
+                  type: string
+      responses:
+        "200":
+          description: ok
+components:
+  schemas:
+    Pet:
+      description: a pet
+      type: object
+      $defs:
+        Tag:
+          type: string
+`
+	results, err := Lint([]byte(content), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the $defs are hoisted as the definitions named by their pointers
+	compareLint(t, results, []swag.LintResult{
+		{
+			Rule:     "description-non-empty",
+			Severity: swag.SeverityWarning,
+			Pointer:  "/definitions/paths~1~01pets~1post~1requestBody~1content~1application~01json~1schema~1$defs~1Name",
+			Line:     17,
+		},
+		{Rule: "description-non-empty", Severity: swag.SeverityWarning, Pointer: "/definitions/Pet~1$defs~1Tag", Line: 28},
+	})
+}
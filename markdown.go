@@ -0,0 +1,195 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+// FormatMarkdown is the name of the GitHub Flavored Markdown renderer.
+const FormatMarkdown = "markdown"
+
+func init() {
+	RegisterRenderer(FormatMarkdown, NewRenderer(".md", renderMarkdown))
+}
+
+func renderMarkdown(w io.Writer, api *swag.API, _ *RenderOption) error {
+	bw := bufio.NewWriter(w)
+	md := &markdownWriter{w: bw}
+	md.document(api)
+	return bw.Flush()
+}
+
+type markdownWriter struct {
+	w *bufio.Writer
+}
+
+func (md *markdownWriter) document(api *swag.API) {
+	title := api.Info.Title
+	if api.Info.Version != "" {
+		title += " `" + api.Info.Version + "`"
+	}
+	md.heading(1, title)
+
+	md.heading(2, "Description")
+	md.paragraph(api.Info.Description)
+
+	md.heading(2, "Server")
+	if len(api.Servers) > 0 {
+		for _, s := range api.Servers {
+			line := "- " + s.Address()
+			if s.Description != "" {
+				line += " - " + s.Description
+			}
+			md.line(line)
+		}
+	} else {
+		for _, scheme := range api.Schemes {
+			md.line("- " + scheme + "://" + api.Host + api.BasePath)
+		}
+	}
+	md.line("")
+
+	md.heading(2, "Definition")
+	for _, group := range api.TagGroups() {
+		md.heading(3, group.Tag.Name)
+		md.paragraph(group.Tag.Description)
+		for _, e := range group.Endpoints {
+			md.endpoint(api, e)
+		}
+	}
+}
+
+func (md *markdownWriter) endpoint(api *swag.API, e *swag.Endpoint) {
+	title := e.Summary
+	if title == "" {
+		title = e.Path
+	}
+	md.heading(4, title)
+	md.paragraph(fmt.Sprintf("`%s` `%s`", e.Method, e.Path))
+	if e.Deprecated {
+		md.paragraph("> **Deprecated**")
+	}
+	md.paragraph(e.Description)
+
+	if params := getParameters(e); len(params) > 0 {
+		md.heading(5, "Request Params")
+		rows := make([][]string, 0, len(params))
+		for _, p := range params {
+			rows = append(rows, []string{p.Name, p.In, p.Type.String(), formatBool(p.Required), p.DefaultValue(), p.Description})
+		}
+		md.table([]string{"name", "position", "type", "required", "default", "description"}, rows)
+	}
+
+	if bodies := getBodyExamples(api, e); len(bodies) > 0 {
+		md.heading(5, "Request Body")
+		if len(e.Consumes) > 0 {
+			md.paragraph("Content-Type: `" + strings.Join(e.Consumes, "`, `") + "`")
+		}
+		md.rows(getBodyRows(api, e))
+		md.examples(bodies)
+	}
+
+	if len(e.Responses) == 0 {
+		return
+	}
+	md.heading(5, "Response")
+	for _, code := range sortedResponseCodes(e.Responses) {
+		res := e.Responses[code]
+		if res == nil {
+			continue
+		}
+		md.paragraph(fmt.Sprintf("**%s** %s", code, res.Description))
+		if len(res.MediaTypes) > 0 {
+			md.paragraph("Content-Type: `" + strings.Join(res.MediaTypes, "`, `") + "`")
+		}
+		if len(res.Headers) > 0 {
+			rows := make([][]string, 0, len(res.Headers))
+			for _, name := range sortedHeaderNames(res.Headers) {
+				h := res.Headers[name]
+				rows = append(rows, []string{name, h.Type, h.Description})
+			}
+			md.table([]string{"header", "type", "description"}, rows)
+		}
+		md.rows(getResponseRows(api, res))
+		md.examples(getResponseExamples(api, res))
+	}
+}
+
+func (md *markdownWriter) rows(rows []swag.Row) {
+	if len(rows) == 0 {
+		return
+	}
+	cells := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		cells = append(cells, []string{"`" + r.Name + "`", r.Type.String(), formatBool(r.Required), r.Enum, r.Constraints, r.Example, r.Description})
+	}
+	md.table([]string{"field", "type", "required", "enum", "constraints", "example", "description"}, cells)
+}
+
+func (md *markdownWriter) examples(examples []example) {
+	for _, v := range examples {
+		if v.Label != "" {
+			md.paragraph("*" + v.Label + "*")
+		}
+		md.line("```json")
+		md.line(v.Body)
+		md.line("```")
+		md.line("")
+	}
+}
+
+func (md *markdownWriter) heading(level int, text string) {
+	md.line(strings.Repeat("#", level) + " " + text)
+	md.line("")
+}
+
+func (md *markdownWriter) paragraph(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	md.line(text)
+	md.line("")
+}
+
+func (md *markdownWriter) table(headers []string, rows [][]string) {
+	md.line("| " + strings.Join(headers, " | ") + " |")
+	md.line(strings.Repeat("| --- ", len(headers)) + "|")
+	for _, row := range rows {
+		cells := make([]string, 0, len(row))
+		for _, cell := range row {
+			cells = append(cells, escapeTableCell(cell))
+		}
+		md.line("| " + strings.Join(cells, " | ") + " |")
+	}
+	md.line("")
+}
+
+func (md *markdownWriter) line(s string) {
+	md.w.WriteString(s)
+	md.w.WriteByte('\n')
+}
+
+var tableCellReplacer = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+func escapeTableCell(s string) string {
+	return tableCellReplacer.Replace(strings.TrimSpace(s))
+}
@@ -0,0 +1,350 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"codeberg.org/go-pdf/fpdf"
+
+	"github.com/zc2638/apidoc/resource"
+	"github.com/zc2638/apidoc/swag"
+)
+
+// NativePDFOption defines the options of the pure Go pdf renderer.
+type NativePDFOption struct {
+	// FontFile is the path of a TrueType(.ttf) font embedded into the document,
+	// it must contain the glyphs of all the texts.
+	// The built-in WenQuanYi Micro Hei font, which supports latin and CJK characters, is used by default.
+	FontFile string
+
+	// Gray renders the document in grayscale.
+	Gray bool
+}
+
+// SaveToNativePDF renders the API to pdf in pure Go, wkhtmltopdf is not required.
+func SaveToNativePDF(api *swag.API, opt NativePDFOption) ([]byte, error) {
+	w, err := newPDFWriter(opt)
+	if err != nil {
+		return nil, err
+	}
+	w.document(api)
+
+	var buf bytes.Buffer
+	if err := w.pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const (
+	pdfMargin     = 15.0
+	pdfLineHeight = 5.0
+	pdfCellPad    = 1.5
+)
+
+type rgb [3]int
+
+var (
+	colorText      = rgb{51, 51, 51}
+	colorLightText = rgb{102, 102, 102}
+	colorWhite     = rgb{255, 255, 255}
+	colorCode      = rgb{51, 51, 51}
+	colorTableHead = rgb{97, 175, 254}
+	colorTableBody = rgb{241, 247, 254}
+	colorBorder    = rgb{204, 204, 204}
+
+	methodColors = map[string]rgb{
+		"GET":     {97, 175, 254},
+		"POST":    {73, 204, 144},
+		"PUT":     {252, 161, 48},
+		"PATCH":   {80, 227, 194},
+		"DELETE":  {249, 62, 62},
+		"HEAD":    {144, 18, 254},
+		"OPTIONS": {13, 90, 167},
+	}
+)
+
+type pdfWriter struct {
+	pdf  *fpdf.Fpdf
+	gray bool
+	font string
+}
+
+func newPDFWriter(opt NativePDFOption) (*pdfWriter, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+
+	var (
+		font []byte
+		err  error
+	)
+	if opt.FontFile != "" {
+		if font, err = os.ReadFile(opt.FontFile); err != nil {
+			return nil, fmt.Errorf("read font file failed: %v", err)
+		}
+	} else if font, err = resource.ReadFont(); err != nil {
+		return nil, fmt.Errorf("read built-in font failed: %v", err)
+	}
+	// only the used glyphs of the font are embedded into the document
+	pdf.AddUTF8FontFromBytes("apidoc", "", font)
+	pdf.AddUTF8FontFromBytes("apidoc", "B", font)
+	pdf.AddPage()
+	return &pdfWriter{pdf: pdf, gray: opt.Gray, font: "apidoc"}, pdf.Error()
+}
+
+func (w *pdfWriter) document(api *swag.API) {
+	title := api.Info.Title
+	if api.Info.Version != "" {
+		title += "  " + api.Info.Version
+	}
+	w.heading(1, title)
+
+	w.heading(2, "Description")
+	w.paragraph(api.Info.Description)
+
+	w.heading(2, "Server")
+	if len(api.Servers) > 0 {
+		for _, s := range api.Servers {
+			line := s.Address()
+			if s.Description != "" {
+				line += "    " + s.Description
+			}
+			w.paragraph(line)
+		}
+	} else {
+		for _, scheme := range api.Schemes {
+			w.paragraph(scheme + "://" + api.Host + api.BasePath)
+		}
+	}
+
+	w.heading(2, "Definition")
+	for _, group := range api.TagGroups() {
+		w.heading(3, group.Tag.Name)
+		w.paragraph(group.Tag.Description)
+		for _, e := range group.Endpoints {
+			w.endpoint(api, e)
+		}
+	}
+}
+
+func (w *pdfWriter) endpoint(api *swag.API, e *swag.Endpoint) {
+	title := e.Summary
+	if title == "" {
+		title = e.Path
+	}
+	w.heading(4, title)
+	w.method(e.Method, e.Path)
+	w.paragraph(e.Description)
+
+	if params := getParameters(e); len(params) > 0 {
+		w.heading(5, "Request Params")
+		rows := make([][]string, 0, len(params))
+		for _, p := range params {
+			rows = append(rows, []string{p.Name, p.In, p.Type.String(), formatBool(p.Required), p.DefaultValue(), p.Description})
+		}
+		w.table([]string{"name", "position", "type", "required", "default", "description"},
+			[]float64{30, 22, 22, 22, 24, 60}, rows)
+	}
+
+	if bodies := getBodyExamples(api, e); len(bodies) > 0 {
+		w.heading(5, "Request Body")
+		if len(e.Consumes) > 0 {
+			w.paragraph("Content-Type: " + strings.Join(e.Consumes, ", "))
+		}
+		w.rows(getBodyRows(api, e))
+		w.examples(bodies)
+	}
+
+	if len(e.Responses) == 0 {
+		return
+	}
+	w.heading(5, "Response")
+	for _, code := range sortedResponseCodes(e.Responses) {
+		res := e.Responses[code]
+		if res == nil {
+			continue
+		}
+		w.paragraph(code + "    " + res.Description)
+		if len(res.MediaTypes) > 0 {
+			w.paragraph("Content-Type: " + strings.Join(res.MediaTypes, ", "))
+		}
+		if len(res.Headers) > 0 {
+			rows := make([][]string, 0, len(res.Headers))
+			for _, name := range sortedHeaderNames(res.Headers) {
+				h := res.Headers[name]
+				rows = append(rows, []string{name, h.Type, h.Description})
+			}
+			w.table([]string{"name", "type", "description"}, []float64{50, 30, 100}, rows)
+		}
+		w.rows(getResponseRows(api, res))
+		w.examples(getResponseExamples(api, res))
+	}
+}
+
+func (w *pdfWriter) rows(rows []swag.Row) {
+	if len(rows) == 0 {
+		return
+	}
+	cells := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		cells = append(cells, []string{r.Name, r.Type.String(), formatBool(r.Required), r.Enum, r.Constraints, r.Example, r.Description})
+	}
+	w.table([]string{"field", "type", "required", "enum", "constraints", "example", "description"},
+		[]float64{36, 18, 18, 24, 24, 24, 36}, cells)
+}
+
+func (w *pdfWriter) examples(examples []example) {
+	for _, v := range examples {
+		if v.Label != "" {
+			w.paragraph(v.Label)
+		}
+		w.code(v.Body)
+	}
+}
+
+func (w *pdfWriter) heading(level int, text string) {
+	sizes := map[int]float64{1: 20, 2: 16, 3: 14, 4: 12, 5: 10.5}
+	size := sizes[level]
+	w.pdf.Ln(2)
+	w.setTextColor(colorText)
+	w.pdf.SetFont(w.font, "B", size)
+	w.pdf.MultiCell(0, size*0.5, text, "", "L", false)
+	w.pdf.Ln(1)
+}
+
+func (w *pdfWriter) paragraph(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	w.setTextColor(colorText)
+	w.pdf.SetFont(w.font, "", 10)
+	w.pdf.MultiCell(0, pdfLineHeight, text, "", "L", false)
+	w.pdf.Ln(1)
+}
+
+// method draws the method label followed by the path, like the method block of the default template.
+func (w *pdfWriter) method(method, path string) {
+	c, ok := methodColors[method]
+	if !ok {
+		c = colorTableHead
+	}
+	w.ensureSpace(8)
+	w.setFillColor(c)
+	w.setTextColor(colorWhite)
+	w.pdf.SetFont(w.font, "B", 10)
+	w.pdf.CellFormat(25, 8, method, "", 0, "C", true, 0, "")
+
+	w.setTextColor(colorText)
+	w.setDrawColor(colorBorder)
+	w.pdf.CellFormat(0, 8, " "+path, "1", 1, "L", false, 0, "")
+	w.pdf.Ln(2)
+}
+
+func (w *pdfWriter) table(headers []string, widths []float64, rows [][]string) {
+	drawRow := func(cells []string, style string, fill, text rgb) {
+		w.pdf.SetFont(w.font, style, 9)
+		lines := make([][]string, len(cells))
+		height := 0.0
+		for i, cell := range cells {
+			lines[i] = w.split(cell, widths[i]-2*pdfCellPad)
+			if h := float64(len(lines[i]))*pdfLineHeight + 2*pdfCellPad; h > height {
+				height = h
+			}
+		}
+		w.ensureSpace(height)
+
+		x, y := w.pdf.GetXY()
+		w.setFillColor(fill)
+		w.setDrawColor(colorWhite)
+		w.setTextColor(text)
+		for i := range cells {
+			w.pdf.Rect(x, y, widths[i], height, "FD")
+			for j, line := range lines[i] {
+				w.pdf.SetXY(x+pdfCellPad, y+pdfCellPad+float64(j)*pdfLineHeight)
+				w.pdf.CellFormat(widths[i]-2*pdfCellPad, pdfLineHeight, line, "", 0, "L", false, 0, "")
+			}
+			x += widths[i]
+		}
+		w.pdf.SetXY(pdfMargin, y+height)
+	}
+
+	drawRow(headers, "B", colorTableHead, colorWhite)
+	for _, row := range rows {
+		drawRow(row, "", colorTableBody, colorLightText)
+	}
+	w.pdf.Ln(2)
+}
+
+func (w *pdfWriter) code(text string) {
+	pageWidth, _ := w.pdf.GetPageSize()
+	width := pageWidth - 2*pdfMargin
+	w.pdf.SetFont(w.font, "", 8.5)
+	w.setFillColor(colorCode)
+	w.setTextColor(colorWhite)
+	for _, line := range w.split(text, width-2*pdfCellPad) {
+		w.pdf.CellFormat(width, 4, line, "", 1, "L", true, 0, "")
+	}
+	w.pdf.Ln(2)
+}
+
+// split splits the text to lines fitting the width with the current font.
+func (w *pdfWriter) split(text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		if paragraph == "" {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, w.pdf.SplitText(paragraph, width)...)
+	}
+	return lines
+}
+
+// ensureSpace starts a new page if the height does not fit in the current page.
+func (w *pdfWriter) ensureSpace(height float64) {
+	_, pageHeight := w.pdf.GetPageSize()
+	if w.pdf.GetY()+height > pageHeight-pdfMargin {
+		w.pdf.AddPage()
+	}
+}
+
+func (w *pdfWriter) setFillColor(c rgb) {
+	r, g, b := w.color(c)
+	w.pdf.SetFillColor(r, g, b)
+}
+
+func (w *pdfWriter) setTextColor(c rgb) {
+	r, g, b := w.color(c)
+	w.pdf.SetTextColor(r, g, b)
+}
+
+func (w *pdfWriter) setDrawColor(c rgb) {
+	r, g, b := w.color(c)
+	w.pdf.SetDrawColor(r, g, b)
+}
+
+func (w *pdfWriter) color(c rgb) (int, int, int) {
+	if !w.gray {
+		return c[0], c[1], c[2]
+	}
+	l := (c[0]*299 + c[1]*587 + c[2]*114) / 1000
+	return l, l, l
+}
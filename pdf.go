@@ -22,7 +22,18 @@ import (
 	pdf "github.com/SebastiaanKlippert/go-wkhtmltopdf"
 )
 
+// blockedProxy is the proxy of the restricted pages, nothing listens on the discard port,
+// so that every request of the page fails without reaching the network.
+const blockedProxy = "http://127.0.0.1:9"
+
 func SaveToPDF(data []byte, isGray bool) ([]byte, error) {
+	return saveToPDF(data, isGray, false)
+}
+
+// saveToPDF converts the html to pdf by wkhtmltopdf.
+// A restricted page can not run javascript, read local files or access the network,
+// the resources failed to load are left out.
+func saveToPDF(data []byte, isGray, restricted bool) ([]byte, error) {
 	gen, err := pdf.NewPDFGenerator()
 	if err != nil {
 		return nil, err
@@ -30,7 +41,16 @@ func SaveToPDF(data []byte, isGray bool) ([]byte, error) {
 	gen.Dpi.Set(300)
 	gen.Grayscale.Set(isGray)
 
-	gen.AddPage(pdf.NewPageReader(bytes.NewReader(data)))
+	page := pdf.NewPageReader(bytes.NewReader(data))
+	if restricted {
+		page.DisableJavascript.Set(true)
+		page.DisableLocalFileAccess.Set(true)
+		page.Proxy.Set(blockedProxy)
+		page.ProxyHostnameLookup.Set(true)
+		page.LoadErrorHandling.Set("ignore")
+		page.LoadMediaErrorHandling.Set("ignore")
+	}
+	gen.AddPage(page)
 	if err := gen.Create(); err != nil {
 		return nil, err
 	}
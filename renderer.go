@@ -0,0 +1,167 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+// The names of the built-in renderers.
+const (
+	FormatHTML      = "html"
+	FormatPDF       = "pdf"
+	FormatNativePDF = "native-pdf"
+)
+
+// RenderOption defines the options passed to a Renderer,
+// a renderer ignores the options it does not support.
+type RenderOption struct {
+	// Template is the template used by template based renderers, see LoadTemplate.
+	Template string
+
+	// Gray renders the document in grayscale.
+	Gray bool
+
+	// FontFile is the font file embedded into the document.
+	FontFile string
+
+	// Restricted marks the spec as provided by an untrusted client, e.g. uploaded to the render server,
+	// the renderers must not read local files or access the network while rendering it.
+	Restricted bool
+}
+
+// Renderer renders the API into an output format.
+type Renderer interface {
+	// Extension returns the file extension of the output, e.g. `.pdf`.
+	Extension() string
+
+	// Render renders the API and writes the output to w.
+	Render(w io.Writer, api *swag.API, opt *RenderOption) error
+}
+
+// NewRenderer returns a Renderer which produces files with the extension by the render function.
+func NewRenderer(ext string, render func(w io.Writer, api *swag.API, opt *RenderOption) error) Renderer {
+	return &renderer{ext: ext, render: render}
+}
+
+type renderer struct {
+	ext    string
+	render func(w io.Writer, api *swag.API, opt *RenderOption) error
+}
+
+func (r *renderer) Extension() string {
+	return r.ext
+}
+
+func (r *renderer) Render(w io.Writer, api *swag.API, opt *RenderOption) error {
+	return r.render(w, api, opt)
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = make(map[string]Renderer)
+)
+
+// RegisterRenderer makes a renderer available by the name,
+// the renderer registered before with the same name is replaced.
+func RegisterRenderer(name string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[name] = r
+}
+
+// GetRenderer returns the renderer registered with the name.
+func GetRenderer(name string) (Renderer, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	r, ok := renderers[name]
+	return r, ok
+}
+
+// Renderers returns the sorted names of all the registered renderers.
+func Renderers() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RenderFormat renders the API with the renderer registered with the name.
+func RenderFormat(w io.Writer, name string, api *swag.API, opt *RenderOption) error {
+	r, ok := GetRenderer(name)
+	if !ok {
+		return fmt.Errorf("renderer %s not found", name)
+	}
+	if opt == nil {
+		opt = &RenderOption{}
+	}
+	return r.Render(w, api, opt)
+}
+
+func init() {
+	RegisterRenderer(FormatHTML, NewRenderer(".html", renderHTML))
+	RegisterRenderer(FormatPDF, NewRenderer(".pdf", renderPDF))
+	RegisterRenderer(FormatNativePDF, NewRenderer(".pdf", renderNativePDF))
+}
+
+func renderHTML(w io.Writer, api *swag.API, opt *RenderOption) error {
+	data, err := Render(api, templateName(opt))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func renderPDF(w io.Writer, api *swag.API, opt *RenderOption) error {
+	data, err := Render(api, templateName(opt))
+	if err != nil {
+		return err
+	}
+	pdfData, err := saveToPDF(data, opt.Gray, opt.Restricted)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(pdfData)
+	return err
+}
+
+func renderNativePDF(w io.Writer, api *swag.API, opt *RenderOption) error {
+	data, err := SaveToNativePDF(api, NativePDFOption{
+		FontFile: opt.FontFile,
+		Gray:     opt.Gray,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func templateName(opt *RenderOption) string {
+	if opt.Template == "" {
+		return DefaultTemplate
+	}
+	return opt.Template
+}
@@ -15,7 +15,12 @@
 package resource
 
 import (
+	"bytes"
+	"compress/gzip"
 	"embed"
+	"io"
+	"io/fs"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -24,12 +29,59 @@ const suffix = ".html"
 
 const templateDir = "template"
 
+const fontFile = "font/wqy-microhei.ttf.gz"
+
 //go:embed *
 var content embed.FS
 
 // ReadTemplate returns template content based on name
 func ReadTemplate(name string) ([]byte, error) {
+	return content.ReadFile(templatePath(name))
+}
+
+// ReadTemplates returns the contents of all the templates in the dir keyed by file name
+func ReadTemplates(dir string) (map[string][]byte, error) {
+	dir = path.Join(templateDir, dir)
+	entries, err := fs.ReadDir(content, dir)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := content.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		out[entry.Name()] = data
+	}
+	return out, nil
+}
+
+// HasTemplate reports whether a built-in template with the name exists
+func HasTemplate(name string) bool {
+	info, err := fs.Stat(content, templatePath(name))
+	return err == nil && !info.IsDir()
+}
+
+func templatePath(name string) string {
 	name = strings.TrimSuffix(name, suffix)
 	name += suffix
-	return content.ReadFile(filepath.Join(templateDir, name))
+	return filepath.Join(templateDir, name)
+}
+
+// ReadFont returns the built-in TrueType font, which contains the CJK glyphs
+func ReadFont() ([]byte, error) {
+	data, err := content.ReadFile(fontFile)
+	if err != nil {
+		return nil, err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
 }
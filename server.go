@@ -0,0 +1,332 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBodySize is the default max size of the uploaded or fetched spec.
+const DefaultMaxBodySize = 10 << 20
+
+// DefaultURLTimeout is the default timeout of fetching the spec from the url query parameter.
+const DefaultURLTimeout = 30 * time.Second
+
+// renderedContentPolicy is the Content-Security-Policy of the rendered documents.
+const renderedContentPolicy = "sandbox; default-src 'none'; style-src 'unsafe-inline'; img-src data:"
+
+// ServerOption defines the options of the render server.
+type ServerOption struct {
+	// Template is used when the request does not specify a built-in template,
+	// it can be a local file or directory like the --template flag.
+	Template string
+
+	// FontFile is used by the native pdf renderer.
+	FontFile string
+
+	// CacheSize is the max number of rendered results kept in memory, the cache is disabled if it is 0.
+	CacheSize int
+
+	// CacheTTL is the lifetime of a cached result, the result never expires if it is 0.
+	CacheTTL time.Duration
+
+	// MaxBodySize is the max size of the uploaded or fetched spec, DefaultMaxBodySize is used if it is 0.
+	MaxBodySize int64
+
+	// AllowURL enables rendering the spec fetched from the url query parameter.
+	// The server fetches any url requested by the clients, including the internal addresses,
+	// so it should only be enabled for trusted clients.
+	AllowURL bool
+
+	// URLTimeout is the timeout of fetching the spec from the url, DefaultURLTimeout is used if it is 0.
+	URLTimeout time.Duration
+
+	// MaxConcurrency is the max number of specs rendered at the same time, the number of CPUs is used if it is 0.
+	// The other requests wait for a free slot until they are canceled.
+	MaxConcurrency int
+}
+
+// Server renders the specs on demand over http.
+//
+// The spec is uploaded by POST /render, either as the raw request body or as the "file" field of a multipart form,
+// or fetched from GET /render?url=<spec-url> when AllowURL is set.
+// The query parameters "format" (default html), "template" (built-in templates only) and "gray"
+// select how the document is rendered.
+type Server struct {
+	opt    ServerOption
+	cache  *renderCache
+	mux    *http.ServeMux
+	client *http.Client
+	slots  chan struct{} // bounds the concurrent renders.
+}
+
+// NewServer returns a render server with the option.
+func NewServer(opt ServerOption) *Server {
+	if opt.Template == "" {
+		opt.Template = DefaultTemplate
+	}
+	if opt.MaxBodySize <= 0 {
+		opt.MaxBodySize = DefaultMaxBodySize
+	}
+	if opt.URLTimeout <= 0 {
+		opt.URLTimeout = DefaultURLTimeout
+	}
+	if opt.MaxConcurrency <= 0 {
+		opt.MaxConcurrency = runtime.NumCPU()
+	}
+	s := &Server{
+		opt:    opt,
+		cache:  newRenderCache(opt.CacheSize, opt.CacheTTL),
+		mux:    http.NewServeMux(),
+		client: &http.Client{Timeout: opt.URLTimeout},
+		slots:  make(chan struct{}, opt.MaxConcurrency),
+	}
+	s.mux.HandleFunc("/render", s.render)
+	s.mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) render(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = FormatHTML
+	}
+	renderer, ok := GetRenderer(format)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unsupported format: %s", format), http.StatusBadRequest)
+		return
+	}
+	opt := &RenderOption{Template: s.opt.Template, FontFile: s.opt.FontFile, Restricted: true}
+	if name := query.Get("template"); name != "" {
+		// local templates are not exposed to the clients
+		if !IsBuiltinTemplate(name) {
+			http.Error(w, fmt.Sprintf("template %s not found", name), http.StatusBadRequest)
+			return
+		}
+		opt.Template = name
+	}
+	if gray := query.Get("gray"); gray != "" {
+		v, err := strconv.ParseBool(gray)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid gray: %s", gray), http.StatusBadRequest)
+			return
+		}
+		opt.Gray = v
+	}
+
+	content, code, err := s.readSpec(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	sum := sha256.Sum256(content)
+	key := strings.Join([]string{hex.EncodeToString(sum[:]), format, opt.Template, strconv.FormatBool(opt.Gray)}, "|")
+	data, ok := s.cache.Get(key)
+	if ok {
+		w.Header().Set("X-Apidoc-Cache", "hit")
+	} else {
+		select {
+		case s.slots <- struct{}{}:
+		case <-r.Context().Done():
+			http.Error(w, "render canceled while waiting for a free slot", http.StatusServiceUnavailable)
+			return
+		}
+		data, code, err = s.renderSpec(content, renderer, opt)
+		<-s.slots
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		s.cache.Add(key, data)
+		w.Header().Set("X-Apidoc-Cache", "miss")
+	}
+
+	// The document is rendered from a spec of the client, whose descriptions may contain any html,
+	// so it is isolated from the origin of the server and can not load anything but its inline styles.
+	w.Header().Set("Content-Security-Policy", renderedContentPolicy)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", contentType(renderer.Extension()))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// renderSpec loads and renders the spec, and returns the status code if it failed.
+// The nodes expanded from the yaml aliases are limited to the size of the spec,
+// so that a small spec can not take more time to load than the large ones allowed.
+func (s *Server) renderSpec(content []byte, renderer Renderer, opt *RenderOption) ([]byte, int, error) {
+	api, err := load(content, len(content))
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+	var buf bytes.Buffer
+	if err := renderer.Render(&buf, api, opt); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return buf.Bytes(), 0, nil
+}
+
+// readSpec returns the spec of the request, and the status code if it failed.
+func (s *Server) readSpec(w http.ResponseWriter, r *http.Request) ([]byte, int, error) {
+	if src := r.URL.Query().Get("url"); src != "" {
+		if r.Method != http.MethodGet {
+			return nil, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+		}
+		if !s.opt.AllowURL {
+			return nil, http.StatusForbidden, fmt.Errorf("url source is disabled")
+		}
+		if !IsURL(src) {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid url: %s", src)
+		}
+		content, err := readURL(s.client, src, s.opt.MaxBodySize)
+		if err != nil {
+			return nil, http.StatusBadGateway, fmt.Errorf("read url failed: %v", err)
+		}
+		return content, 0, nil
+	}
+
+	if r.Method != http.MethodPost {
+		return nil, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.opt.MaxBodySize)
+
+	var body io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, readErrorCode(err), fmt.Errorf("read form file failed: %v", err)
+		}
+		defer file.Close()
+		body = file
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, readErrorCode(err), fmt.Errorf("read body failed: %v", err)
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("empty spec")
+	}
+	return content, 0, nil
+}
+
+// readErrorCode returns the status code of the error reading the request body.
+func readErrorCode(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func contentType(ext string) string {
+	if ext == ".md" {
+		return "text/markdown; charset=utf-8"
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// renderCache is a LRU cache of the rendered results.
+type renderCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	data    []byte
+	expires time.Time
+}
+
+func newRenderCache(size int, ttl time.Duration) *renderCache {
+	return &renderCache{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *renderCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.data, true
+}
+
+func (c *renderCache) Add(key string, data []byte) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = time.Now().Add(c.ttl)
+	}
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.data, entry.expires = data, expires
+		c.ll.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, data: data, expires: expires})
+	for c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *renderCache) remove(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*cacheEntry).key)
+}
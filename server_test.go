@@ -0,0 +1,253 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	content, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func multipartBody(t *testing.T, content []byte) (io.Reader, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", "swagger.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf, mw.FormDataContentType()
+}
+
+func TestServerStatus(t *testing.T) {
+	spec := readTestdata(t, "swagger.json")
+	// the limit leaves room for the multipart envelope of the spec
+	srv := NewServer(ServerOption{MaxBodySize: int64(len(spec)) + 1024})
+	large := append(bytes.Repeat([]byte(" "), 2048), spec...)
+	largeForm, largeFormType := multipartBody(t, large)
+	form, formType := multipartBody(t, spec)
+
+	cases := []struct {
+		name        string
+		method      string
+		target      string
+		body        io.Reader
+		contentType string
+		want        int
+	}{
+		{name: "raw body", method: http.MethodPost, target: "/render", body: bytes.NewReader(spec), want: http.StatusOK},
+		{name: "form file", method: http.MethodPost, target: "/render", body: form, contentType: formType, want: http.StatusOK},
+		{name: "unsupported format", method: http.MethodPost, target: "/render?format=docx", body: bytes.NewReader(spec), want: http.StatusBadRequest},
+		{name: "local template", method: http.MethodPost, target: "/render?template=/etc/passwd", body: bytes.NewReader(spec), want: http.StatusBadRequest},
+		{name: "invalid gray", method: http.MethodPost, target: "/render?gray=maybe", body: bytes.NewReader(spec), want: http.StatusBadRequest},
+		{name: "empty body", method: http.MethodPost, target: "/render", body: strings.NewReader(" \n"), want: http.StatusBadRequest},
+		{name: "form without file", method: http.MethodPost, target: "/render", body: strings.NewReader(""), contentType: formType, want: http.StatusBadRequest},
+		{name: "body too large", method: http.MethodPost, target: "/render", body: bytes.NewReader(large), want: http.StatusRequestEntityTooLarge},
+		{name: "form too large", method: http.MethodPost, target: "/render", body: largeForm, contentType: largeFormType, want: http.StatusRequestEntityTooLarge},
+		{name: "invalid spec", method: http.MethodPost, target: "/render", body: strings.NewReader(`{"swagger": "2.0", "paths": []}`), want: http.StatusUnprocessableEntity},
+		{name: "get without url", method: http.MethodGet, target: "/render", want: http.StatusMethodNotAllowed},
+		{name: "url disabled", method: http.MethodGet, target: "/render?url=http://127.0.0.1/swagger.json", want: http.StatusForbidden},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, c.target, c.body)
+			if c.contentType != "" {
+				req.Header.Set("Content-Type", c.contentType)
+			}
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			if rec.Code != c.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, c.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServerRenderedHeaders(t *testing.T) {
+	srv := NewServer(ServerOption{})
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/render", bytes.NewReader(readTestdata(t, "swagger.yaml"))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	h := rec.Header()
+	if got := h.Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Errorf("Content-Type = %s, want text/html", got)
+	}
+	if got := h.Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %s, want nosniff", got)
+	}
+	csp := h.Get("Content-Security-Policy")
+	for _, directive := range []string{"sandbox", "default-src 'none'"} {
+		if !strings.Contains(csp, directive) {
+			t.Errorf("Content-Security-Policy = %s, want %s", csp, directive)
+		}
+	}
+}
+
+func TestServerCache(t *testing.T) {
+	srv := NewServer(ServerOption{CacheSize: 10})
+	spec := readTestdata(t, "swagger.json")
+	render := func(target string) string {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, bytes.NewReader(spec)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", target, rec.Code, rec.Body.String())
+		}
+		return rec.Header().Get("X-Apidoc-Cache")
+	}
+
+	for _, step := range []struct {
+		target string
+		want   string
+	}{
+		{target: "/render", want: "miss"},
+		{target: "/render", want: "hit"},
+		{target: "/render?format=markdown", want: "miss"},
+		{target: "/render?template=table", want: "miss"},
+		{target: "/render?format=html", want: "hit"},
+	} {
+		if got := render(step.target); got != step.want {
+			t.Errorf("%s: cache %s, want %s", step.target, got, step.want)
+		}
+	}
+}
+
+func TestServerMaxConcurrency(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	RegisterRenderer("test-blocking", NewRenderer(".txt", func(w io.Writer, api *swag.API, opt *RenderOption) error {
+		started <- struct{}{}
+		<-release
+		_, err := io.WriteString(w, api.Info.Title)
+		return err
+	}))
+	srv := NewServer(ServerOption{MaxConcurrency: 1})
+	spec := readTestdata(t, "swagger.json")
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/render?format=test-blocking", bytes.NewReader(spec)))
+		done <- rec.Code
+	}()
+	<-started
+
+	// the only slot is taken, so the second render waits until the request is canceled
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/render?format=test-blocking", bytes.NewReader(spec)).WithContext(ctx))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("waiting render status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("first render status = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestRenderCacheEviction(t *testing.T) {
+	c := newRenderCache(2, 0)
+	c.Add("a", []byte("a"))
+	c.Add("b", []byte("b"))
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a is not cached")
+	}
+	// b is the least recently used one
+	c.Add("c", []byte("c"))
+	if _, ok := c.Get("b"); ok {
+		t.Error("b is not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if data, ok := c.Get(key); !ok || string(data) != key {
+			t.Errorf("%s = %q, %v, want it cached", key, data, ok)
+		}
+	}
+
+	disabled := newRenderCache(0, 0)
+	disabled.Add("a", []byte("a"))
+	if _, ok := disabled.Get("a"); ok {
+		t.Error("a is cached by the disabled cache")
+	}
+}
+
+func TestRenderCacheTTL(t *testing.T) {
+	c := newRenderCache(2, 20*time.Millisecond)
+	c.Add("a", []byte("a"))
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a is not cached")
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Error("a is not expired")
+	}
+	if c.ll.Len() != 0 || len(c.items) != 0 {
+		t.Errorf("%d entries are kept after the expiry", c.ll.Len())
+	}
+
+	// adding the key again renews it
+	c.Add("a", []byte("a"))
+	if _, ok := c.Get("a"); !ok {
+		t.Error("a is not cached again")
+	}
+}
+
+func TestServerAliases(t *testing.T) {
+	srv := NewServer(ServerOption{})
+	// the aliases of a small spec expanding to more nodes than its size are rejected by the server only
+	bomb := aliasBomb(2)
+	if _, err := Load([]byte(bomb)); err != nil {
+		t.Fatalf("the aliases within the default limits are rejected: %v", err)
+	}
+	for _, c := range []struct {
+		content string
+		want    string
+	}{
+		{content: "swagger: \"2.0\"\ninfo: {title: a, version: \"1\"}\npaths: {}\nx-a: &a {b: *a}\n", want: "anchor 'a' value contains itself"},
+		{content: bomb, want: errExcessiveAliasing.Error()},
+	} {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/render", strings.NewReader(c.content)))
+		if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), c.want) {
+			t.Errorf("status = %d with %s, want %d with %s", rec.Code, rec.Body.String(), http.StatusUnprocessableEntity, c.want)
+		}
+	}
+}
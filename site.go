@@ -0,0 +1,314 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zc2638/apidoc/resource"
+	"github.com/zc2638/apidoc/swag"
+)
+
+// FormatSite is the name of the static site output, which is written to a directory instead of a renderer.
+const FormatSite = "site"
+
+const siteTemplateDir = "site"
+
+// GenerateSite renders the API into a static site in the dir,
+// which consists of an index page, one page per tag, per operation and per definition.
+// All the pages are linked with each other by relative links,
+// so the dir can be served by any static file host.
+func GenerateSite(api *swag.API, dir string) error {
+	s := newSite(api)
+	pages, err := s.pages()
+	if err != nil {
+		return err
+	}
+	for name, data := range pages {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("create site dir failed: %v", err)
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return fmt.Errorf("write site page failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// sitePage is the data used to render a page of the site.
+type sitePage struct {
+	API   *swag.API
+	Title string
+
+	// Root is the relative path from the page to the root of the site.
+	Root string
+
+	Groups      []swag.TagGroup
+	Definitions []string
+
+	Group      *swag.TagGroup
+	Endpoint   *swag.Endpoint
+	Definition string
+	Schema     *swag.Schema
+
+	// UsedBy lists the endpoints using the definition of the page.
+	UsedBy []*swag.Endpoint
+}
+
+type site struct {
+	api         *swag.API
+	groups      []swag.TagGroup
+	definitions []string
+
+	tagSlugs        map[string]string
+	operationSlugs  map[*swag.Endpoint]string
+	definitionSlugs map[string]string
+	definitionRefs  map[string][]*swag.Endpoint
+}
+
+func newSite(api *swag.API) *site {
+	s := &site{
+		api:             api,
+		groups:          api.TagGroups(),
+		tagSlugs:        make(map[string]string),
+		operationSlugs:  make(map[*swag.Endpoint]string),
+		definitionSlugs: make(map[string]string),
+		definitionRefs:  make(map[string][]*swag.Endpoint),
+	}
+	for name := range api.Definitions {
+		s.definitions = append(s.definitions, name)
+	}
+	sort.Strings(s.definitions)
+
+	used := make(map[string]struct{})
+	for _, name := range s.definitions {
+		s.definitionSlugs[name] = uniqueSlug(used, name)
+	}
+
+	used = make(map[string]struct{})
+	for _, g := range s.groups {
+		s.tagSlugs[g.Tag.Name] = uniqueSlug(used, g.Tag.Name)
+	}
+
+	used = make(map[string]struct{})
+	for _, e := range api.AllEndpoints() {
+		name := e.OperationID
+		if name == "" {
+			name = e.Method + " " + e.Path
+		}
+		s.operationSlugs[e] = uniqueSlug(used, name)
+
+		seen := make(map[string]struct{})
+		for _, ref := range endpointRefs(e) {
+			name, ok := swag.DefinitionName(ref)
+			if !ok {
+				continue
+			}
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			s.definitionRefs[name] = append(s.definitionRefs[name], e)
+		}
+	}
+	return s
+}
+
+// pages renders all the pages keyed by their slash separated paths.
+func (s *site) pages() (map[string][]byte, error) {
+	files, err := resource.ReadTemplates(siteTemplateDir)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := template.New("layout.html").Funcs(s.funcs()).Parse(string(files["layout.html"]))
+	if err != nil {
+		return nil, err
+	}
+	pageTemplate := func(name string) (*template.Template, error) {
+		t, err := layout.Clone()
+		if err != nil {
+			return nil, err
+		}
+		// the page is parsed under its own name, so only its definitions
+		// are shared with the layout and the layout body is kept.
+		if _, err := t.New(name).Parse(string(files[name])); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+
+	out := make(map[string][]byte)
+	out["style.css"] = files["style.css"]
+	render := func(tplName, target string, page *sitePage) error {
+		t, err := pageTemplate(tplName)
+		if err != nil {
+			return err
+		}
+		page.API, page.Groups, page.Definitions = s.api, s.groups, s.definitions
+		page.Root = strings.Repeat("../", strings.Count(target, "/"))
+
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, page); err != nil {
+			return fmt.Errorf("render %s failed: %v", target, err)
+		}
+		out[target] = buf.Bytes()
+		return nil
+	}
+
+	if err := render("index.html", "index.html", &sitePage{Title: s.api.Info.Title}); err != nil {
+		return nil, err
+	}
+	for i := range s.groups {
+		g := &s.groups[i]
+		if err := render("tag.html", s.tagURL(g.Tag.Name), &sitePage{Title: g.Tag.Name, Group: g}); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range s.api.AllEndpoints() {
+		title := e.Summary
+		if title == "" {
+			title = e.Method + " " + e.Path
+		}
+		if err := render("operation.html", s.operationURL(e), &sitePage{Title: title, Endpoint: e}); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range s.definitions {
+		page := &sitePage{
+			Title:      name,
+			Definition: name,
+			Schema:     &swag.Schema{Ref: swag.DefinitionRef(name)},
+			UsedBy:     s.definitionRefs[name],
+		}
+		if err := render("definition.html", s.definitionURL(name), page); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (s *site) funcs() template.FuncMap {
+	fm := make(template.FuncMap, len(funcMap)+7)
+	for k, v := range funcMap {
+		fm[k] = v
+	}
+	fm["tagURL"] = s.tagURL
+	fm["operationURL"] = s.operationURL
+	fm["definitionURL"] = s.definitionURL
+	fm["schemaDefinition"] = schemaDefinition
+	fm["getBodySchema"] = getBodySchema
+	fm["getSchemaRows"] = getSchemaRows
+	fm["getSchemaExamples"] = getExamples
+	return fm
+}
+
+func (s *site) tagURL(name string) string {
+	return "tags/" + s.tagSlugs[name] + ".html"
+}
+
+func (s *site) operationURL(e *swag.Endpoint) string {
+	return "operations/" + s.operationSlugs[e] + ".html"
+}
+
+func (s *site) definitionURL(name string) string {
+	return "definitions/" + s.definitionSlugs[name] + ".html"
+}
+
+// schemaDefinition returns the name of the definition the schema or its items refer to.
+func schemaDefinition(schema *swag.Schema) string {
+	for schema != nil {
+		if name, ok := swag.DefinitionName(schema.Ref); ok {
+			return name
+		}
+		schema = schema.Items
+	}
+	return ""
+}
+
+func getBodySchema(e *swag.Endpoint) *swag.Schema {
+	for _, p := range e.Parameters {
+		if p.In == "body" {
+			return p.Schema
+		}
+	}
+	return nil
+}
+
+func getSchemaRows(api *swag.API, schema *swag.Schema) []swag.Row {
+	return api.GetRows(schema)
+}
+
+// endpointRefs returns all the references used by the parameters and responses of the endpoint.
+func endpointRefs(e *swag.Endpoint) []string {
+	var refs []string
+	for _, p := range e.Parameters {
+		if p.Schema != nil {
+			refs = append(refs, p.Schema.Refs()...)
+		}
+		for _, c := range p.Contents {
+			if c.Schema != nil {
+				refs = append(refs, c.Schema.Refs()...)
+			}
+		}
+	}
+	for _, code := range sortedResponseCodes(e.Responses) {
+		res := e.Responses[code]
+		if res == nil {
+			continue
+		}
+		if res.Schema != nil {
+			refs = append(refs, res.Schema.Refs()...)
+		}
+		for _, c := range res.Contents {
+			if c.Schema != nil {
+				refs = append(refs, c.Schema.Refs()...)
+			}
+		}
+	}
+	return refs
+}
+
+var slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(s string) string {
+	slug := strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if slug == "" {
+		return "index"
+	}
+	return slug
+}
+
+// uniqueSlug returns the slug of the name which is not used yet, and marks it as used.
+func uniqueSlug(used map[string]struct{}, name string) string {
+	base := slugify(name)
+	slug := base
+	for i := 2; ; i++ {
+		if _, ok := used[slug]; !ok {
+			break
+		}
+		slug = base + "-" + strconv.Itoa(i)
+	}
+	used[slug] = struct{}{}
+	return slug
+}
@@ -0,0 +1,282 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"sort"
+	"strings"
+)
+
+// CoverageMetric counts the items covered by the documentation.
+type CoverageMetric struct {
+	Total   int     `json:"total"`
+	Covered int     `json:"covered"`
+	Percent float64 `json:"percent"`
+}
+
+func (m *CoverageMetric) add(covered bool) {
+	m.Total++
+	if covered {
+		m.Covered++
+	}
+}
+
+func (m *CoverageMetric) merge(other CoverageMetric) {
+	m.Total += other.Total
+	m.Covered += other.Covered
+}
+
+// percent sets the percent of the covered items, nothing to cover is fully covered.
+func (m *CoverageMetric) percent() {
+	m.Percent = 100
+	if m.Total > 0 {
+		m.Percent = float64(m.Covered) * 100 / float64(m.Total)
+	}
+}
+
+// CoverageCategory is the documentation coverage of a kind of items.
+type CoverageCategory struct {
+	Description CoverageMetric `json:"description"`
+	Example     CoverageMetric `json:"example"`
+}
+
+// CoverageOffender is an operation or definition missing the documentation.
+type CoverageOffender struct {
+	// Kind is either "operation" or "definition".
+	Kind string `json:"kind"`
+
+	// Name is the method and path of an operation, or the name of a definition.
+	Name string `json:"name"`
+
+	// Missing lists the missing descriptions and examples.
+	Missing []string `json:"missing"`
+}
+
+// CoverageReport is the documentation coverage of an API.
+type CoverageReport struct {
+	Operations CoverageCategory `json:"operations"`
+	Parameters CoverageCategory `json:"parameters"`
+	Properties CoverageCategory `json:"properties"`
+
+	// Total sums the descriptions and examples of all the items.
+	Total CoverageMetric `json:"total"`
+
+	// Offenders are ordered by the number of the missing documentation, the worst first.
+	Offenders []CoverageOffender `json:"offenders"`
+}
+
+// Coverage computes how many operations, parameters and definition properties
+// have descriptions and examples.
+//
+// An operation is described by its summary or description,
+// and has examples if its request body or any response schema declares one.
+// The examples of the properties referring to other definitions are counted in those definitions.
+func Coverage(api *API) *CoverageReport {
+	r := &CoverageReport{Offenders: []CoverageOffender{}}
+	for _, e := range api.AllEndpoints() {
+		r.operation(api, e)
+	}
+	for _, name := range sortedSchemaNames(api.Definitions) {
+		r.definition(api, name, api.Definitions[name])
+	}
+
+	for _, c := range []*CoverageCategory{&r.Operations, &r.Parameters, &r.Properties} {
+		c.Description.percent()
+		c.Example.percent()
+		r.Total.merge(c.Description)
+		r.Total.merge(c.Example)
+	}
+	r.Total.percent()
+
+	sort.SliceStable(r.Offenders, func(i, j int) bool {
+		return len(r.Offenders[i].Missing) > len(r.Offenders[j].Missing)
+	})
+	return r
+}
+
+func (r *CoverageReport) operation(api *API, e *Endpoint) {
+	var missing []string
+	described := strings.TrimSpace(e.Summary) != "" || strings.TrimSpace(e.Description) != ""
+	r.Operations.Description.add(described)
+	if !described {
+		missing = append(missing, "description")
+	}
+
+	var schemas []*Schema
+	for _, param := range e.Parameters {
+		if param.Schema != nil {
+			schemas = append(schemas, param.Schema)
+		}
+		for _, c := range param.Contents {
+			if c.Schema != nil {
+				schemas = append(schemas, c.Schema)
+			}
+		}
+	}
+	for _, code := range sortedResponseCodes(e.Responses) {
+		res := e.Responses[code]
+		if res == nil {
+			continue
+		}
+		if res.Schema != nil {
+			schemas = append(schemas, res.Schema)
+		}
+		for _, c := range res.Contents {
+			if c.Schema != nil {
+				schemas = append(schemas, c.Schema)
+			}
+		}
+	}
+	if len(schemas) > 0 {
+		exampled := false
+		for _, s := range schemas {
+			if hasExample(api, s, make(map[*Schema]struct{})) {
+				exampled = true
+				break
+			}
+		}
+		r.Operations.Example.add(exampled)
+		if !exampled {
+			missing = append(missing, "example")
+		}
+	}
+
+	for _, param := range e.Parameters {
+		described := strings.TrimSpace(param.Description) != ""
+		r.Parameters.Description.add(described)
+		if !described {
+			missing = append(missing, "description of parameter "+param.Name)
+		}
+		exampled := param.Example != nil
+		if param.Schema != nil {
+			exampled = exampled || hasExample(api, param.Schema, make(map[*Schema]struct{}))
+		}
+		r.Parameters.Example.add(exampled)
+		if !exampled {
+			missing = append(missing, "example of parameter "+param.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		r.Offenders = append(r.Offenders, CoverageOffender{
+			Kind:    "operation",
+			Name:    e.Method + " " + e.Path,
+			Missing: missing,
+		})
+	}
+}
+
+func (r *CoverageReport) definition(api *API, name string, s *Schema) {
+	var missing []string
+	r.properties(api, s, "", &missing, make(map[*Schema]struct{}))
+	if len(missing) > 0 {
+		r.Offenders = append(r.Offenders, CoverageOffender{
+			Kind:    "definition",
+			Name:    name,
+			Missing: missing,
+		})
+	}
+}
+
+// properties counts the properties of the schema and of its inline object properties and items.
+func (r *CoverageReport) properties(api *API, s *Schema, prefix string, missing *[]string, visited map[*Schema]struct{}) {
+	if s == nil {
+		return
+	}
+	if _, ok := visited[s]; ok {
+		return
+	}
+	visited[s] = struct{}{}
+
+	for _, v := range s.AllOf {
+		if v.Ref == "" {
+			r.properties(api, v, prefix, missing, visited)
+		}
+	}
+	if s.Items != nil && s.Items.Ref == "" {
+		r.properties(api, s.Items, prefix, missing, visited)
+	}
+
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := s.Properties[name]
+		if prop == nil {
+			continue
+		}
+		field := prefix + name
+
+		description := prop.Description
+		if description == "" && prop.Ref != "" {
+			if target := api.resolveSchema(prop); target != nil {
+				description = target.Description
+			}
+		}
+		described := strings.TrimSpace(description) != ""
+		r.Properties.Description.add(described)
+		if !described {
+			*missing = append(*missing, "description of "+field)
+		}
+
+		if prop.Ref == "" && !isContainer(prop) {
+			exampled := prop.example() != ""
+			r.Properties.Example.add(exampled)
+			if !exampled {
+				*missing = append(*missing, "example of "+field)
+			}
+		}
+		if prop.Ref == "" {
+			r.properties(api, prop, field+".", missing, visited)
+		}
+	}
+}
+
+// isContainer reports whether the schema is an object or an array of objects,
+// whose examples are made of the examples of their properties.
+func isContainer(s *Schema) bool {
+	if len(s.Properties) > 0 || len(s.AllOf) > 0 || s.Type == Object {
+		return true
+	}
+	if s.Type == Array && s.Items != nil {
+		return s.Items.Ref != "" || isContainer(s.Items)
+	}
+	return false
+}
+
+// hasExample reports whether the schema or any of its nested schemas declares an example.
+func hasExample(api *API, s *Schema, visited map[*Schema]struct{}) bool {
+	if s == nil {
+		return false
+	}
+	if _, ok := visited[s]; ok {
+		return false
+	}
+	visited[s] = struct{}{}
+	if s.example() != "" {
+		return true
+	}
+	if s.Ref != "" {
+		return hasExample(api, api.resolveSchema(s), visited)
+	}
+	for _, v := range s.subSchemas() {
+		if hasExample(api, v, visited) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"reflect"
+	"testing"
+)
+
+// coverageAPI has an operation documented with examples, an undocumented one,
+// and a definition whose inline object properties are not documented.
+func coverageAPI() *API {
+	return &API{
+		Paths: map[string]*Endpoints{
+			"/pets": {
+				Get: &Endpoint{
+					Summary: "list pets",
+					Parameters: []Parameter{
+						{Name: "limit", In: "query", Description: "page size", Example: 10},
+					},
+					Responses: map[string]*Response{"200": {Schema: &Schema{Ref: "#/definitions/Pet"}}},
+				},
+				Post: &Endpoint{
+					Parameters: []Parameter{
+						{Name: "body", In: "body", Schema: &Schema{Ref: "#/definitions/Tag"}},
+					},
+				},
+			},
+		},
+		Definitions: map[string]*Schema{
+			"Pet": {
+				Type: Object,
+				Properties: map[string]*Schema{
+					"name": {Type: String, Description: "the name", Example: "rex"},
+					"tag":  {Ref: "#/definitions/Tag"},
+					"owner": {Type: Object, Properties: map[string]*Schema{
+						"id": {Type: Integer},
+					}},
+				},
+			},
+			"Tag": {Type: String, Description: "a tag", Example: "dog"},
+		},
+	}
+}
+
+func TestCoverageNothingToCover(t *testing.T) {
+	r := Coverage(&API{})
+	for _, m := range []CoverageMetric{
+		r.Operations.Description, r.Operations.Example,
+		r.Parameters.Description, r.Parameters.Example,
+		r.Properties.Description, r.Properties.Example,
+		r.Total,
+	} {
+		if m != (CoverageMetric{Percent: 100}) {
+			t.Errorf("metric = %+v, want fully covered without items", m)
+		}
+	}
+	// the offenders are encoded as an empty array
+	if r.Offenders == nil || len(r.Offenders) != 0 {
+		t.Errorf("offenders = %#v, want empty", r.Offenders)
+	}
+}
+
+func TestCoverageMetrics(t *testing.T) {
+	r := Coverage(coverageAPI())
+
+	// the referred Tag describes the tag property, its example is counted in Tag only
+	want := map[string]CoverageMetric{
+		"operation descriptions": {Total: 2, Covered: 1, Percent: 50},
+		"operation examples":     {Total: 2, Covered: 2, Percent: 100},
+		"parameter descriptions": {Total: 2, Covered: 1, Percent: 50},
+		"parameter examples":     {Total: 2, Covered: 2, Percent: 100},
+		"property descriptions":  {Total: 4, Covered: 2, Percent: 50},
+		"property examples":      {Total: 2, Covered: 1, Percent: 50},
+	}
+	got := map[string]CoverageMetric{
+		"operation descriptions": r.Operations.Description,
+		"operation examples":     r.Operations.Example,
+		"parameter descriptions": r.Parameters.Description,
+		"parameter examples":     r.Parameters.Example,
+		"property descriptions":  r.Properties.Description,
+		"property examples":      r.Properties.Example,
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s = %+v, want %+v", name, got[name], w)
+		}
+	}
+	if r.Total.Total != 14 || r.Total.Covered != 9 {
+		t.Errorf("total = %+v, want 9 of 14", r.Total)
+	}
+}
+
+func TestCoverageOffenders(t *testing.T) {
+	r := Coverage(coverageAPI())
+	want := []CoverageOffender{
+		{Kind: "definition", Name: "Pet", Missing: []string{"description of owner", "description of owner.id", "example of owner.id"}},
+		{Kind: "operation", Name: "POST /pets", Missing: []string{"description", "description of parameter body"}},
+	}
+	if !reflect.DeepEqual(r.Offenders, want) {
+		t.Errorf("offenders = %+v, want the worst first %+v", r.Offenders, want)
+	}
+}
@@ -0,0 +1,543 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeType classifies a change between two versions of an API.
+type ChangeType string
+
+const (
+	PathAdded             ChangeType = "path-added"
+	PathRemoved           ChangeType = "path-removed"
+	OperationAdded        ChangeType = "operation-added"
+	OperationRemoved      ChangeType = "operation-removed"
+	OperationDeprecated   ChangeType = "operation-deprecated"
+	ParameterAdded        ChangeType = "parameter-added"
+	ParameterRemoved      ChangeType = "parameter-removed"
+	ParameterTypeChanged  ChangeType = "parameter-type-changed"
+	ParameterRequired     ChangeType = "parameter-required"
+	ParameterOptional     ChangeType = "parameter-optional"
+	ResponseAdded         ChangeType = "response-added"
+	ResponseRemoved       ChangeType = "response-removed"
+	FieldAdded            ChangeType = "field-added"
+	FieldRemoved          ChangeType = "field-removed"
+	FieldTypeChanged      ChangeType = "field-type-changed"
+	FieldRequired         ChangeType = "field-required"
+	FieldOptional         ChangeType = "field-optional"
+	EnumValuesAdded       ChangeType = "enum-values-added"
+	EnumValuesRemoved     ChangeType = "enum-values-removed"
+	EnumAdded             ChangeType = "enum-added"
+	EnumRemoved           ChangeType = "enum-removed"
+	RequestBodyAdded      ChangeType = "request-body-added"
+	RequestBodyRemoved    ChangeType = "request-body-removed"
+	RequestBodyRequired   ChangeType = "request-body-required"
+	ResponseSchemaAdded   ChangeType = "response-schema-added"
+	ResponseSchemaRemoved ChangeType = "response-schema-removed"
+)
+
+// BreakingCategory classifies a breaking change by how the clients are broken.
+type BreakingCategory string
+
+const (
+	RemovedEndpoint      BreakingCategory = "removed-endpoint"
+	RemovedResponse      BreakingCategory = "removed-response"
+	NewRequiredParameter BreakingCategory = "new-required-parameter"
+	NarrowedEnum         BreakingCategory = "narrowed-enum"
+	WidenedResponseEnum  BreakingCategory = "widened-response-enum"
+	ChangedFieldType     BreakingCategory = "changed-field-type"
+	RemovedResponseField BreakingCategory = "removed-response-field"
+)
+
+// breakingCategories maps the types of the breaking changes to their categories.
+var breakingCategories = map[ChangeType]BreakingCategory{
+	PathRemoved:           RemovedEndpoint,
+	OperationRemoved:      RemovedEndpoint,
+	ResponseRemoved:       RemovedResponse,
+	ParameterAdded:        NewRequiredParameter,
+	ParameterRequired:     NewRequiredParameter,
+	RequestBodyAdded:      NewRequiredParameter,
+	RequestBodyRequired:   NewRequiredParameter,
+	FieldAdded:            NewRequiredParameter,
+	FieldRequired:         NewRequiredParameter,
+	EnumValuesRemoved:     NarrowedEnum,
+	EnumAdded:             NarrowedEnum,
+	EnumValuesAdded:       WidenedResponseEnum,
+	EnumRemoved:           WidenedResponseEnum,
+	ParameterTypeChanged:  ChangedFieldType,
+	FieldTypeChanged:      ChangedFieldType,
+	FieldRemoved:          RemovedResponseField,
+	FieldOptional:         RemovedResponseField,
+	ResponseSchemaRemoved: RemovedResponseField,
+}
+
+// Change is a difference between two versions of an API.
+type Change struct {
+	Type   ChangeType `json:"type"`
+	Path   string     `json:"path"`
+	Method string     `json:"method,omitempty"`
+
+	// Location is where the change is in the operation,
+	// e.g. "query parameter limit", "request body field pet.name" or "response 200 field id".
+	Location string `json:"location,omitempty"`
+
+	Message string `json:"message"`
+
+	// Breaking reports whether the existing clients may be broken by the change.
+	Breaking bool `json:"breaking"`
+
+	// Category is set for the breaking changes.
+	Category BreakingCategory `json:"category,omitempty"`
+}
+
+// Changelog is the list of the changes between two versions of an API.
+type Changelog struct {
+	Title         string   `json:"title"`
+	BaseVersion   string   `json:"baseVersion"`
+	TargetVersion string   `json:"targetVersion"`
+	Changes       []Change `json:"changes"`
+}
+
+// Breaking returns the breaking changes.
+func (c *Changelog) Breaking() []Change {
+	var out []Change
+	for _, v := range c.Changes {
+		if v.Breaking {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// HasBreaking reports whether any breaking change exists.
+func (c *Changelog) HasBreaking() bool {
+	for _, v := range c.Changes {
+		if v.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff compares the target version of an API with the base version.
+//
+// Paths, operations, parameters, response codes and the fields of the request and response bodies are compared.
+// A change is breaking if the existing clients may be broken, e.g. a removed operation, a new required parameter,
+// a narrowed request enum, a changed field type or a removed response field.
+func Diff(base, target *API) *Changelog {
+	title := target.Info.Title
+	if title == "" {
+		title = base.Info.Title
+	}
+	d := &differ{
+		base:   base,
+		target: target,
+		log: &Changelog{
+			Title:         title,
+			BaseVersion:   base.Info.Version,
+			TargetVersion: target.Info.Version,
+		},
+	}
+	d.paths()
+	return d.log
+}
+
+type differ struct {
+	base   *API
+	target *API
+	log    *Changelog
+}
+
+// direction tells how a schema is used, which decides whether a change of it is breaking.
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+func (d *differ) add(c Change) {
+	if c.Breaking {
+		c.Category = breakingCategories[c.Type]
+	}
+	d.log.Changes = append(d.log.Changes, c)
+}
+
+func (d *differ) paths() {
+	set := make(map[string]struct{})
+	for p := range d.base.Paths {
+		set[p] = struct{}{}
+	}
+	for p := range d.target.Paths {
+		set[p] = struct{}{}
+	}
+	paths := make([]string, 0, len(set))
+	for p := range set {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		oldEs, newEs := d.base.Paths[p], d.target.Paths[p]
+		switch {
+		case oldEs == nil:
+			d.add(Change{Type: PathAdded, Path: p, Message: "path added"})
+		case newEs == nil:
+			d.add(Change{Type: PathRemoved, Path: p, Message: "path removed", Breaking: true})
+		default:
+			d.operations(p, oldEs, newEs)
+		}
+	}
+}
+
+func (d *differ) operations(path string, oldEs, newEs *Endpoints) {
+	olds := make(map[string]*Endpoint)
+	for _, e := range oldEs.All() {
+		olds[e.Method] = e
+	}
+	news := make(map[string]*Endpoint)
+	for _, e := range newEs.All() {
+		news[e.Method] = e
+	}
+
+	for _, e := range oldEs.All() {
+		if _, ok := news[e.Method]; !ok {
+			d.add(Change{Type: OperationRemoved, Path: path, Method: e.Method, Message: "operation removed", Breaking: true})
+		}
+	}
+	for _, e := range newEs.All() {
+		old, ok := olds[e.Method]
+		if !ok {
+			d.add(Change{Type: OperationAdded, Path: path, Method: e.Method, Message: "operation added"})
+			continue
+		}
+		d.operation(path, old, e)
+	}
+}
+
+func (d *differ) operation(path string, old, e *Endpoint) {
+	change := func(c Change) {
+		c.Path, c.Method = path, e.Method
+		d.add(c)
+	}
+	if !old.Deprecated && e.Deprecated {
+		change(Change{Type: OperationDeprecated, Message: "operation deprecated"})
+	}
+
+	d.parameters(change, old, e)
+	d.requestBody(change, old, e)
+	d.responses(change, old, e)
+}
+
+func (d *differ) parameters(change func(Change), old, e *Endpoint) {
+	key := func(p Parameter) string { return p.In + " parameter " + p.Name }
+	olds := make(map[string]Parameter)
+	for _, p := range old.Parameters {
+		if p.In != "body" {
+			olds[key(p)] = p
+		}
+	}
+	news := make(map[string]Parameter)
+	for _, p := range e.Parameters {
+		if p.In != "body" {
+			news[key(p)] = p
+		}
+	}
+
+	for _, p := range old.Parameters {
+		if _, ok := news[key(p)]; !ok && p.In != "body" {
+			change(Change{Type: ParameterRemoved, Location: key(p), Message: "parameter removed"})
+		}
+	}
+	for _, p := range e.Parameters {
+		if p.In == "body" {
+			continue
+		}
+		location := key(p)
+		op, ok := olds[location]
+		if !ok {
+			if p.Required {
+				change(Change{Type: ParameterAdded, Location: location, Message: "required parameter added", Breaking: true})
+			} else {
+				change(Change{Type: ParameterAdded, Location: location, Message: "optional parameter added"})
+			}
+			continue
+		}
+		if op.Type != p.Type && op.Type != "" && p.Type != "" {
+			change(Change{
+				Type:     ParameterTypeChanged,
+				Location: location,
+				Message:  fmt.Sprintf("type changed from %s to %s", op.Type, p.Type),
+				Breaking: true,
+			})
+		}
+		switch {
+		case !op.Required && p.Required:
+			change(Change{Type: ParameterRequired, Location: location, Message: "parameter became required", Breaking: true})
+		case op.Required && !p.Required:
+			change(Change{Type: ParameterOptional, Location: location, Message: "parameter became optional"})
+		}
+		d.enum(change, location, request, stringValues(op.Enum), stringValues(p.Enum))
+	}
+}
+
+func (d *differ) requestBody(change func(Change), old, e *Endpoint) {
+	oldBody, newBody := bodyParameter(old), bodyParameter(e)
+	switch {
+	case oldBody == nil && newBody == nil:
+		return
+	case oldBody == nil:
+		change(Change{Type: RequestBodyAdded, Location: "request body", Message: "request body added", Breaking: newBody.Required})
+		return
+	case newBody == nil:
+		change(Change{Type: RequestBodyRemoved, Location: "request body", Message: "request body removed"})
+		return
+	}
+	if !oldBody.Required && newBody.Required {
+		change(Change{Type: RequestBodyRequired, Location: "request body", Message: "request body became required", Breaking: true})
+	}
+	d.schema(change, "request body", request, oldBody.Schema, newBody.Schema)
+}
+
+func (d *differ) responses(change func(Change), old, e *Endpoint) {
+	for _, code := range sortedResponseCodes(old.Responses) {
+		if _, ok := e.Responses[code]; !ok {
+			// clients relying on a success response are broken, while an error response is just no longer returned
+			change(Change{
+				Type:     ResponseRemoved,
+				Location: "response " + code,
+				Message:  "response removed",
+				Breaking: strings.HasPrefix(code, "2"),
+			})
+		}
+	}
+	for _, code := range sortedResponseCodes(e.Responses) {
+		location := "response " + code
+		res := e.Responses[code]
+		oldRes, ok := old.Responses[code]
+		if !ok {
+			change(Change{Type: ResponseAdded, Location: location, Message: "response added"})
+			continue
+		}
+		var oldSchema, newSchema *Schema
+		if oldRes != nil {
+			oldSchema = oldRes.Schema
+		}
+		if res != nil {
+			newSchema = res.Schema
+		}
+		switch {
+		case oldSchema == nil && newSchema == nil:
+		case oldSchema == nil:
+			change(Change{Type: ResponseSchemaAdded, Location: location, Message: "response body added"})
+		case newSchema == nil:
+			change(Change{Type: ResponseSchemaRemoved, Location: location, Message: "response body removed", Breaking: true})
+		default:
+			d.schema(change, location, response, oldSchema, newSchema)
+		}
+	}
+}
+
+// schema compares the fields of the schemas, the fields nested in an added or removed field are omitted.
+func (d *differ) schema(change func(Change), location string, dir direction, old, s *Schema) {
+	d.fieldType(change, location, dir, resolvedType(d.base, old), resolvedType(d.target, s))
+
+	oldRows := d.base.GetRows(old)
+	newRows := d.target.GetRows(s)
+	olds := make(map[string]Row, len(oldRows))
+	for _, r := range oldRows {
+		olds[r.Name] = r
+	}
+	news := make(map[string]Row, len(newRows))
+	for _, r := range newRows {
+		news[r.Name] = r
+	}
+
+	var removed, added []string
+	nested := func(parents []string, name string) bool {
+		for _, p := range parents {
+			if strings.HasPrefix(name, p+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, r := range oldRows {
+		if _, ok := news[r.Name]; ok || nested(removed, r.Name) {
+			continue
+		}
+		removed = append(removed, r.Name)
+		change(Change{
+			Type:     FieldRemoved,
+			Location: location + " field " + r.Name,
+			Message:  "field removed",
+			Breaking: dir == response,
+		})
+	}
+	for _, r := range newRows {
+		fieldLocation := location + " field " + r.Name
+		or, ok := olds[r.Name]
+		if !ok {
+			if nested(added, r.Name) {
+				continue
+			}
+			added = append(added, r.Name)
+			if dir == request && r.Required {
+				change(Change{Type: FieldAdded, Location: fieldLocation, Message: "required field added", Breaking: true})
+			} else {
+				change(Change{Type: FieldAdded, Location: fieldLocation, Message: "field added"})
+			}
+			continue
+		}
+		d.fieldType(change, fieldLocation, dir, or.Type.String(), r.Type.String())
+		switch {
+		case !or.Required && r.Required:
+			change(Change{Type: FieldRequired, Location: fieldLocation, Message: "field became required", Breaking: dir == request})
+		case or.Required && !r.Required:
+			change(Change{Type: FieldOptional, Location: fieldLocation, Message: "field became optional", Breaking: dir == response})
+		}
+		d.enum(change, fieldLocation, dir, or.EnumValues, r.EnumValues)
+	}
+}
+
+// fieldType compares the types joined by `|`, allowing null is breaking for a response
+// and disallowing null is breaking for a request, any other change of the types is breaking.
+// An empty type is not declared and can not be compared.
+func (d *differ) fieldType(change func(Change), location string, dir direction, old, typ string) {
+	if old == "" || typ == "" {
+		return
+	}
+	oldTypes, types := strings.Split(old, " | "), strings.Split(typ, " | ")
+	removed, added := subtract(oldTypes, types), subtract(types, oldTypes)
+	switch {
+	case len(removed) == 0 && len(added) == 0:
+	case len(removed) == 0 && len(added) == 1 && added[0] == Null.String():
+		change(Change{Type: FieldTypeChanged, Location: location, Message: "field became nullable", Breaking: dir == response})
+	case len(added) == 0 && len(removed) == 1 && removed[0] == Null.String():
+		change(Change{Type: FieldTypeChanged, Location: location, Message: "field became non-nullable", Breaking: dir == request})
+	default:
+		change(Change{
+			Type:     FieldTypeChanged,
+			Location: location,
+			Message:  fmt.Sprintf("type changed from %s to %s", old, typ),
+			Breaking: true,
+		})
+	}
+}
+
+// enum compares the allowed values, narrowing a request enum or widening a response enum is breaking.
+func (d *differ) enum(change func(Change), location string, dir direction, old, values []string) {
+	if len(old) == 0 && len(values) == 0 {
+		return
+	}
+	// an enum introduced or dropped entirely changes whether the values are restricted at all
+	switch {
+	case len(old) == 0:
+		change(Change{
+			Type:     EnumAdded,
+			Location: location,
+			Message:  "enum added: " + strings.Join(values, ", "),
+			Breaking: dir == request,
+		})
+		return
+	case len(values) == 0:
+		change(Change{
+			Type:     EnumRemoved,
+			Location: location,
+			Message:  "enum removed, any value is allowed",
+			Breaking: dir == response,
+		})
+		return
+	}
+
+	removed := subtract(old, values)
+	added := subtract(values, old)
+	if len(removed) > 0 {
+		change(Change{
+			Type:     EnumValuesRemoved,
+			Location: location,
+			Message:  "enum values removed: " + strings.Join(removed, ", "),
+			Breaking: dir == request,
+		})
+	}
+	if len(added) > 0 {
+		change(Change{
+			Type:     EnumValuesAdded,
+			Location: location,
+			Message:  "enum values added: " + strings.Join(added, ", "),
+			Breaking: dir == response,
+		})
+	}
+}
+
+// resolvedType returns the type of the schema, following the references to the definitions.
+func resolvedType(api *API, s *Schema) string {
+	seen := make(map[string]struct{})
+	for s != nil {
+		name, ok := DefinitionName(s.Ref)
+		if !ok {
+			break
+		}
+		if _, ok := seen[name]; ok {
+			return ""
+		}
+		seen[name] = struct{}{}
+		s = api.Definitions[name]
+	}
+	if s == nil {
+		return ""
+	}
+	if s.Type == "" && len(s.Types) == 0 && len(s.Properties) > 0 {
+		return Object.String()
+	}
+	return s.typeName().String()
+}
+
+func bodyParameter(e *Endpoint) *Parameter {
+	for i := range e.Parameters {
+		if e.Parameters[i].In == "body" {
+			return &e.Parameters[i]
+		}
+	}
+	return nil
+}
+
+func stringValues(values []interface{}) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, valueToString(v))
+	}
+	return out
+}
+
+// subtract returns the values of a which are not in b.
+func subtract(a, b []string) []string {
+	set := make(map[string]struct{}, len(b))
+	for _, v := range b {
+		set[v] = struct{}{}
+	}
+	var out []string
+	for _, v := range a {
+		if _, ok := set[v]; !ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,404 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffFieldType(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, typ string
+		dir      direction
+		want     string
+		breaking bool
+	}{
+		{name: "unchanged", old: "integer", typ: "integer", dir: request},
+		{name: "reordered", old: "integer | null", typ: "null | integer", dir: response},
+		{name: "nullable request", old: "string", typ: "string | null", dir: request, want: "field became nullable"},
+		{name: "nullable response", old: "string", typ: "string | null", dir: response, want: "field became nullable", breaking: true},
+		{name: "non-nullable request", old: "string | null", typ: "string", dir: request, want: "field became non-nullable", breaking: true},
+		{name: "non-nullable response", old: "string | null", typ: "string", dir: response, want: "field became non-nullable"},
+		{name: "changed", old: "string", typ: "integer | null", dir: request, want: "type changed from string to integer | null", breaking: true},
+		{name: "declared", old: "", typ: "object", dir: response},
+		{name: "undeclared", old: "object", typ: "", dir: request},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var changes []Change
+			d := &differ{}
+			d.fieldType(func(c Change) { changes = append(changes, c) }, "field id", tt.dir, tt.old, tt.typ)
+			if tt.want == "" {
+				if len(changes) != 0 {
+					t.Fatalf("changes = %+v, want none", changes)
+				}
+				return
+			}
+			if len(changes) != 1 || changes[0].Message != tt.want || changes[0].Breaking != tt.breaking {
+				t.Fatalf("changes = %+v, want %q with breaking %v", changes, tt.want, tt.breaking)
+			}
+		})
+	}
+}
+
+// diffAPI returns the base api of the diff tests changed by the mutate functions.
+// Pet is the request body of POST /pets and the response of GET /pets/{id}.
+func diffAPI(t *testing.T, mutates ...func(*API)) *API {
+	t.Helper()
+	api := &API{
+		Info: Info{Title: "pets", Version: "1.0"},
+		Paths: map[string]*Endpoints{
+			"/pets": {
+				Get: &Endpoint{
+					Parameters: []Parameter{
+						{Name: "limit", In: "query", Type: Integer},
+						{Name: "status", In: "query", Type: String, Enum: []interface{}{"available", "sold"}},
+					},
+					Responses: map[string]*Response{
+						"200": {Description: "names", Schema: &Schema{Type: Array, Items: &Schema{Type: String}}},
+						"404": {Description: "not found"},
+					},
+				},
+				Post: &Endpoint{
+					Parameters: []Parameter{{Name: "pet", In: "body", Schema: &Schema{Ref: "#/definitions/Pet"}}},
+					Responses:  map[string]*Response{"201": {Description: "created"}},
+				},
+			},
+			"/pets/{id}": {
+				Get: &Endpoint{
+					Parameters: []Parameter{{Name: "id", In: "path", Type: String, Required: true}},
+					Responses:  map[string]*Response{"200": {Description: "the pet", Schema: &Schema{Ref: "#/definitions/Pet"}}},
+				},
+				Delete: &Endpoint{
+					Parameters: []Parameter{{Name: "id", In: "path", Type: String, Required: true}},
+					Responses:  map[string]*Response{"204": {Description: "deleted"}},
+				},
+			},
+		},
+		Definitions: map[string]*Schema{
+			"Pet": {
+				Type:     Object,
+				Required: []string{"id", "name"},
+				Properties: map[string]*Schema{
+					"id":   {Type: Integer},
+					"name": {Type: String},
+					"tag":  {Type: String, Enum: []string{"dog", "cat"}},
+				},
+			},
+		},
+	}
+	for _, mutate := range mutates {
+		mutate(api)
+	}
+	if err := api.TransformSchemas(); err != nil {
+		t.Fatal(err)
+	}
+	return api
+}
+
+// changeSummary is the part of a change asserted by the diff tests.
+type changeSummary struct {
+	Type     ChangeType
+	Method   string
+	Path     string
+	Location string
+	Breaking bool
+	Category BreakingCategory
+}
+
+func summarize(changes []Change) []changeSummary {
+	var out []changeSummary
+	for _, c := range changes {
+		out = append(out, changeSummary{
+			Type:     c.Type,
+			Method:   c.Method,
+			Path:     c.Path,
+			Location: c.Location,
+			Breaking: c.Breaking,
+			Category: c.Category,
+		})
+	}
+	return out
+}
+
+func TestDiffBreakingCategories(t *testing.T) {
+	pet := func(api *API) *Schema { return api.Definitions["Pet"] }
+	tests := []struct {
+		name   string
+		mutate func(*API)
+		want   []changeSummary
+	}{
+		{
+			name:   "removed path",
+			mutate: func(api *API) { delete(api.Paths, "/pets/{id}") },
+			want:   []changeSummary{{Type: PathRemoved, Path: "/pets/{id}", Breaking: true, Category: RemovedEndpoint}},
+		},
+		{
+			name:   "removed operation",
+			mutate: func(api *API) { api.Paths["/pets/{id}"].Delete = nil },
+			want:   []changeSummary{{Type: OperationRemoved, Method: "DELETE", Path: "/pets/{id}", Breaking: true, Category: RemovedEndpoint}},
+		},
+		{
+			name: "new required parameter",
+			mutate: func(api *API) {
+				get := api.Paths["/pets"].Get
+				get.Parameters = append(get.Parameters, Parameter{Name: "owner", In: "query", Type: String, Required: true})
+			},
+			want: []changeSummary{{Type: ParameterAdded, Method: "GET", Path: "/pets", Location: "query parameter owner", Breaking: true, Category: NewRequiredParameter}},
+		},
+		{
+			name:   "parameter became required",
+			mutate: func(api *API) { api.Paths["/pets"].Get.Parameters[0].Required = true },
+			want:   []changeSummary{{Type: ParameterRequired, Method: "GET", Path: "/pets", Location: "query parameter limit", Breaking: true, Category: NewRequiredParameter}},
+		},
+		{
+			name: "new required body",
+			mutate: func(api *API) {
+				del := api.Paths["/pets/{id}"].Delete
+				del.Parameters = append(del.Parameters, Parameter{Name: "reason", In: "body", Required: true, Schema: &Schema{Type: String}})
+			},
+			want: []changeSummary{{Type: RequestBodyAdded, Method: "DELETE", Path: "/pets/{id}", Location: "request body", Breaking: true, Category: NewRequiredParameter}},
+		},
+		{
+			name:   "new required request field",
+			mutate: func(api *API) { pet(api).Required = append(pet(api).Required, "tag") },
+			want: []changeSummary{
+				{Type: FieldRequired, Method: "POST", Path: "/pets", Location: "request body field tag", Breaking: true, Category: NewRequiredParameter},
+				{Type: FieldRequired, Method: "GET", Path: "/pets/{id}", Location: "response 200 field tag"},
+			},
+		},
+		{
+			name:   "narrowed enum",
+			mutate: func(api *API) { api.Paths["/pets"].Get.Parameters[1].Enum = []interface{}{"available"} },
+			want:   []changeSummary{{Type: EnumValuesRemoved, Method: "GET", Path: "/pets", Location: "query parameter status", Breaking: true, Category: NarrowedEnum}},
+		},
+		{
+			// an enum introduced on an unrestricted parameter narrows it, not only the values removed from an enum
+			name:   "added enum",
+			mutate: func(api *API) { api.Paths["/pets"].Get.Parameters[0].Enum = []interface{}{10, 20} },
+			want:   []changeSummary{{Type: EnumAdded, Method: "GET", Path: "/pets", Location: "query parameter limit", Breaking: true, Category: NarrowedEnum}},
+		},
+		{
+			// the values are compared one by one, so the order of an enum is not a change
+			name:   "reordered enum",
+			mutate: func(api *API) { pet(api).Properties["tag"].Enum = []string{"cat", "dog"} },
+		},
+		{
+			name:   "widened response enum",
+			mutate: func(api *API) { pet(api).Properties["tag"].Enum = []string{"dog", "cat", "bird"} },
+			want: []changeSummary{
+				{Type: EnumValuesAdded, Method: "POST", Path: "/pets", Location: "request body field tag"},
+				{Type: EnumValuesAdded, Method: "GET", Path: "/pets/{id}", Location: "response 200 field tag", Breaking: true, Category: WidenedResponseEnum},
+			},
+		},
+		{
+			name:   "removed response enum",
+			mutate: func(api *API) { pet(api).Properties["tag"].Enum = nil },
+			want: []changeSummary{
+				{Type: EnumRemoved, Method: "POST", Path: "/pets", Location: "request body field tag"},
+				{Type: EnumRemoved, Method: "GET", Path: "/pets/{id}", Location: "response 200 field tag", Breaking: true, Category: WidenedResponseEnum},
+			},
+		},
+		{
+			name:   "changed parameter type",
+			mutate: func(api *API) { api.Paths["/pets"].Get.Parameters[0].Type = String },
+			want:   []changeSummary{{Type: ParameterTypeChanged, Method: "GET", Path: "/pets", Location: "query parameter limit", Breaking: true, Category: ChangedFieldType}},
+		},
+		{
+			name:   "changed field type",
+			mutate: func(api *API) { pet(api).Properties["id"].Type = String },
+			want: []changeSummary{
+				{Type: FieldTypeChanged, Method: "POST", Path: "/pets", Location: "request body field id", Breaking: true, Category: ChangedFieldType},
+				{Type: FieldTypeChanged, Method: "GET", Path: "/pets/{id}", Location: "response 200 field id", Breaking: true, Category: ChangedFieldType},
+			},
+		},
+		{
+			name:   "removed response field",
+			mutate: func(api *API) { delete(pet(api).Properties, "tag") },
+			want: []changeSummary{
+				{Type: FieldRemoved, Method: "POST", Path: "/pets", Location: "request body field tag"},
+				{Type: FieldRemoved, Method: "GET", Path: "/pets/{id}", Location: "response 200 field tag", Breaking: true, Category: RemovedResponseField},
+			},
+		},
+		{
+			name:   "optional response field",
+			mutate: func(api *API) { pet(api).Required = []string{"id"} },
+			want: []changeSummary{
+				{Type: FieldOptional, Method: "POST", Path: "/pets", Location: "request body field name"},
+				{Type: FieldOptional, Method: "GET", Path: "/pets/{id}", Location: "response 200 field name", Breaking: true, Category: RemovedResponseField},
+			},
+		},
+		{
+			name:   "removed success response",
+			mutate: func(api *API) { delete(api.Paths["/pets"].Get.Responses, "200") },
+			want:   []changeSummary{{Type: ResponseRemoved, Method: "GET", Path: "/pets", Location: "response 200", Breaking: true, Category: RemovedResponse}},
+		},
+		{
+			name:   "removed error response",
+			mutate: func(api *API) { delete(api.Paths["/pets"].Get.Responses, "404") },
+			want:   []changeSummary{{Type: ResponseRemoved, Method: "GET", Path: "/pets", Location: "response 404"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := Diff(diffAPI(t), diffAPI(t, tt.mutate))
+			got := summarize(cl.Changes)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %+v, want %+v", got, tt.want)
+			}
+			if cl.HasBreaking() != (len(cl.Breaking()) > 0) {
+				t.Errorf("HasBreaking = %v with %d breaking changes", cl.HasBreaking(), len(cl.Breaking()))
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	type change struct {
+		Type     ChangeType
+		Method   string
+		Path     string
+		Location string
+		Message  string
+	}
+	pet := func(api *API) *Schema { return api.Definitions["Pet"] }
+	tests := []struct {
+		name   string
+		base   func(*API)
+		target func(*API)
+		want   []change
+	}{
+		{
+			name:   "unchanged",
+			target: func(*API) {},
+		},
+		{
+			name: "added path",
+			target: func(api *API) {
+				api.Paths["/owners"] = &Endpoints{Get: &Endpoint{Responses: map[string]*Response{"200": {Description: "ok"}}}}
+			},
+			want: []change{{Type: PathAdded, Path: "/owners", Message: "path added"}},
+		},
+		{
+			name: "added and removed operations",
+			target: func(api *API) {
+				api.Paths["/pets/{id}"].Put, api.Paths["/pets/{id}"].Delete = api.Paths["/pets/{id}"].Delete, nil
+			},
+			want: []change{
+				{Type: OperationRemoved, Method: "DELETE", Path: "/pets/{id}", Message: "operation removed"},
+				{Type: OperationAdded, Method: "PUT", Path: "/pets/{id}", Message: "operation added"},
+			},
+		},
+		{
+			name:   "deprecated operation",
+			target: func(api *API) { api.Paths["/pets/{id}"].Delete.Deprecated = true },
+			want:   []change{{Type: OperationDeprecated, Method: "DELETE", Path: "/pets/{id}", Message: "operation deprecated"}},
+		},
+		{
+			name: "parameters",
+			base: func(api *API) { api.Paths["/pets"].Get.Parameters[0].Required = true },
+			target: func(api *API) {
+				api.Paths["/pets"].Get.Parameters = []Parameter{
+					{Name: "limit", In: "query", Type: Integer},
+					{Name: "offset", In: "query", Type: Integer},
+				}
+			},
+			want: []change{
+				{Type: ParameterRemoved, Method: "GET", Path: "/pets", Location: "query parameter status", Message: "parameter removed"},
+				{Type: ParameterOptional, Method: "GET", Path: "/pets", Location: "query parameter limit", Message: "parameter became optional"},
+				{Type: ParameterAdded, Method: "GET", Path: "/pets", Location: "query parameter offset", Message: "optional parameter added"},
+			},
+		},
+		{
+			name: "parameter type",
+			base: func(api *API) { api.Paths["/pets"].Get.Parameters[0].Type = "" },
+			// an undeclared type is not compared
+			target: func(api *API) {},
+		},
+		{
+			name: "response codes",
+			target: func(api *API) {
+				responses := api.Paths["/pets"].Get.Responses
+				delete(responses, "404")
+				responses["400"] = &Response{Description: "bad request"}
+			},
+			want: []change{
+				{Type: ResponseRemoved, Method: "GET", Path: "/pets", Location: "response 404", Message: "response removed"},
+				{Type: ResponseAdded, Method: "GET", Path: "/pets", Location: "response 400", Message: "response added"},
+			},
+		},
+		{
+			name: "response bodies",
+			target: func(api *API) {
+				api.Paths["/pets"].Get.Responses["200"].Schema = nil
+				api.Paths["/pets"].Post.Responses["201"].Schema = &Schema{Ref: "#/definitions/Pet"}
+			},
+			want: []change{
+				{Type: ResponseSchemaRemoved, Method: "GET", Path: "/pets", Location: "response 200", Message: "response body removed"},
+				{Type: ResponseSchemaAdded, Method: "POST", Path: "/pets", Location: "response 201", Message: "response body added"},
+			},
+		},
+		{
+			name: "fields",
+			target: func(api *API) {
+				pet(api).Required = []string{"name", "tag"}
+				pet(api).Properties["age"] = &Schema{Type: Integer}
+			},
+			want: []change{
+				{Type: FieldAdded, Method: "POST", Path: "/pets", Location: "request body field age", Message: "field added"},
+				{Type: FieldOptional, Method: "POST", Path: "/pets", Location: "request body field id", Message: "field became optional"},
+				{Type: FieldRequired, Method: "POST", Path: "/pets", Location: "request body field tag", Message: "field became required"},
+				{Type: FieldAdded, Method: "GET", Path: "/pets/{id}", Location: "response 200 field age", Message: "field added"},
+				{Type: FieldOptional, Method: "GET", Path: "/pets/{id}", Location: "response 200 field id", Message: "field became optional"},
+				{Type: FieldRequired, Method: "GET", Path: "/pets/{id}", Location: "response 200 field tag", Message: "field became required"},
+			},
+		},
+		{
+			name: "field type",
+			base: func(api *API) { pet(api).Properties["owner"] = &Schema{} },
+			// an undeclared type is unknown rather than changed
+			target: func(api *API) {
+				pet(api).Properties["owner"] = &Schema{Type: Object, Properties: map[string]*Schema{"name": {Type: String}}}
+				pet(api).Properties["name"].Types = []ParameterType{String, Null}
+			},
+			want: []change{
+				{Type: FieldTypeChanged, Method: "POST", Path: "/pets", Location: "request body field name", Message: "field became nullable"},
+				{Type: FieldAdded, Method: "POST", Path: "/pets", Location: "request body field owner.name", Message: "field added"},
+				{Type: FieldTypeChanged, Method: "GET", Path: "/pets/{id}", Location: "response 200 field name", Message: "field became nullable"},
+				{Type: FieldAdded, Method: "GET", Path: "/pets/{id}", Location: "response 200 field owner.name", Message: "field added"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the target is changed from the base
+			var mutates []func(*API)
+			if tt.base != nil {
+				mutates = append(mutates, tt.base)
+			}
+			base := diffAPI(t, mutates...)
+			cl := Diff(base, diffAPI(t, append(mutates, tt.target)...))
+			var got []change
+			for _, c := range cl.Changes {
+				got = append(got, change{Type: c.Type, Method: c.Method, Path: c.Path, Location: c.Location, Message: c.Message})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,651 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultExampleSeed is the seed of the default example generator.
+const DefaultExampleSeed int64 = 1
+
+var defaultExampleGenerator = NewExampleGenerator(DefaultExampleSeed)
+
+// ExampleGenerator converts the schemas to json example values,
+// and generates the values of the schemas without examples.
+//
+// A generated value honours the default, enum, format, bounds and pattern of the schema,
+// and falls back to the heuristics on the property name.
+// The values only depend on the seed, the property name and the schema,
+// so the same document is always rendered with the same examples.
+type ExampleGenerator struct {
+	seed int64
+}
+
+// NewExampleGenerator returns an example generator with the seed.
+func NewExampleGenerator(seed int64) *ExampleGenerator {
+	return &ExampleGenerator{seed: seed}
+}
+
+// ConvertSchemaToMap converts the definitions to json example values keyed by ref.
+// A recursive reference is omitted from the example, an array of it is empty,
+// so that the examples still match their schemas.
+func (g *ExampleGenerator) ConvertSchemaToMap(schemas map[string]*Schema) (map[string]interface{}, error) {
+	// 解析为 ref => obj
+	set := make(map[string]interface{})
+	err := resolveDefinitions(schemas, func(ref, _ string, _ *Schema) {
+		set[ref] = recursiveRef{}
+	}, func(ref string, s *Schema) {
+		if obj, ok := g.ConvertSchemaToValue(set, s); ok {
+			set[ref] = obj
+		}
+	})
+	for ref, obj := range set {
+		if isRecursiveRef(obj) {
+			delete(set, ref)
+		}
+	}
+	return set, err
+}
+
+// ConvertSchemaToValue converts the schema to a json example value,
+// the references are resolved by the set converted from the definitions.
+func (g *ExampleGenerator) ConvertSchemaToValue(set map[string]interface{}, schema *Schema) (interface{}, bool) {
+	obj, ok := g.convert(set, schema, "")
+	if isRecursiveRef(obj) {
+		return nil, ok
+	}
+	return obj, ok
+}
+
+// recursiveRef stands for a definition being converted,
+// the recursive references to it are left out of the examples.
+type recursiveRef struct{}
+
+func isRecursiveRef(v interface{}) bool {
+	_, ok := v.(recursiveRef)
+	return ok
+}
+
+// convert converts the schema of the named property, the name of an array is used by its items.
+func (g *ExampleGenerator) convert(set map[string]interface{}, schema *Schema, name string) (interface{}, bool) {
+	if schema.Ref != "" {
+		if obj, ok := set[schema.Ref]; ok {
+			return obj, true
+		}
+		return nil, false
+	}
+	if schema.Const != nil {
+		return schema.Const, true
+	}
+	var value interface{}
+	switch schema.Type {
+	case String, Integer, Number, Boolean:
+		value = g.scalar(schema, name)
+	case Array:
+		objs := make([]interface{}, 0, len(schema.PrefixItems)+1)
+		for _, s := range schema.PrefixItems {
+			obj, ok := g.convert(set, s, name)
+			if !ok {
+				return nil, false
+			}
+			if isRecursiveRef(obj) {
+				obj = nil
+			}
+			objs = append(objs, obj)
+		}
+		if schema.Items != nil && !schema.Items.isFalse {
+			n := itemCount(schema, len(objs))
+			shortened := n > exampleMaxItems
+			if shortened {
+				n = exampleMaxItems
+			}
+			items, ok := g.items(set, schema, name, n)
+			if !ok {
+				return nil, false
+			}
+			objs = append(objs, items...)
+			if shortened {
+				objs = append(objs, exampleEllipsis)
+			}
+		}
+		value = objs
+	case Object:
+		objs := make(map[string]interface{})
+		for propName, s := range schema.Properties {
+			obj, ok := g.convert(set, s, propName)
+			if !ok {
+				return nil, false
+			}
+			if isRecursiveRef(obj) {
+				continue
+			}
+			objs[propName] = obj
+		}
+		if schema.hasAdditionalProperties() {
+			obj, ok := g.convert(set, schema.AdditionalProperties, name)
+			if !ok {
+				return nil, false
+			}
+			if obj == nil {
+				// the values of a free-form map can be anything
+				obj = "value"
+			}
+			if _, ok := objs[exampleMapKey]; !ok && !isRecursiveRef(obj) {
+				objs[exampleMapKey] = obj
+			}
+		}
+		value = objs
+	}
+
+	// allOf is merged into the value, and the first of the oneOf/anyOf alternatives is used.
+	parts := schema.AllOf
+	if len(schema.OneOf) > 0 {
+		parts = append(parts[:len(parts):len(parts)], schema.OneOf[0])
+	}
+	if len(schema.AnyOf) > 0 {
+		parts = append(parts[:len(parts):len(parts)], schema.AnyOf[0])
+	}
+	for _, s := range parts {
+		obj, ok := g.convert(set, s, name)
+		if !ok {
+			return nil, false
+		}
+		if isRecursiveRef(obj) {
+			continue
+		}
+		value = mergeValue(value, obj)
+	}
+	return value, true
+}
+
+// exampleMapKey is the key of the example entry of a map.
+const exampleMapKey = "key"
+
+const (
+	// exampleMaxItems and exampleMaxLength cap the generated arrays and strings,
+	// a larger minItems or minLength is not met but marked by exampleEllipsis.
+	exampleMaxItems  = 3
+	exampleMaxLength = 64
+	exampleEllipsis  = "..."
+)
+
+// scalar returns the example of the string, integer, number or boolean schema,
+// which is generated if the schema has no example.
+func (g *ExampleGenerator) scalar(s *Schema, name string) interface{} {
+	if example := s.example(); example != "" {
+		return typedValue(s.Type, example)
+	}
+	if s.Default != nil {
+		return typedValue(s.Type, valueToString(s.Default))
+	}
+	if len(s.Enum) > 0 {
+		return typedValue(s.Type, s.Enum[0])
+	}
+
+	r := g.rand(s, name)
+	switch s.Type {
+	case Integer:
+		return toInt(numberExample(s, r, true))
+	case Number:
+		return numberExample(s, r, false)
+	case Boolean:
+		return booleanByName(name, r)
+	}
+	v, matched := stringExample(s, name, r)
+	if matched {
+		// padding or truncating would break the match of the pattern
+		return v
+	}
+	return fitLength(s, v)
+}
+
+// items generates n items of the array with different seeds,
+// the duplicate items are generated again if the array requires unique items.
+func (g *ExampleGenerator) items(set map[string]interface{}, schema *Schema, name string, n int) ([]interface{}, bool) {
+	out := make([]interface{}, 0, n)
+	seen := make(map[string]struct{}, n)
+	for seed, attempts := g.seed, 0; len(out) < n; seed++ {
+		obj, ok := (&ExampleGenerator{seed: seed}).convert(set, schema.Items, name)
+		if !ok {
+			return nil, false
+		}
+		if isRecursiveRef(obj) {
+			// an empty array never refers to itself
+			return out, true
+		}
+		if schema.UniqueItems && attempts < n*10 {
+			attempts++
+			key := fmt.Sprintf("%#v", obj)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+		}
+		out = append(out, obj)
+	}
+	return out, true
+}
+
+// itemCount returns the number of the generated items of the array with the prefix items,
+// one item is generated unless minItems or maxItems requires another number.
+func itemCount(s *Schema, prefix int) int {
+	n := 1
+	if s.MinItems != nil && *s.MinItems-prefix > n {
+		n = *s.MinItems - prefix
+	}
+	if s.MaxItems != nil && prefix+n > *s.MaxItems {
+		n = *s.MaxItems - prefix
+	}
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// numberExample returns a number within the bounds of the schema, which is a multiple of multipleOf if set.
+func numberExample(s *Schema, r *rand.Rand, integer bool) float64 {
+	min, max := bounds(s, 1, 100)
+	step := 0.01
+	if integer {
+		step = 1
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		step = *s.MultipleOf
+		if integer {
+			step = integerStep(step)
+		}
+	}
+
+	// the candidates are the multiples of the step within the bounds
+	lower, upper := math.Ceil(min/step), math.Floor(max/step)
+	if math.IsInf(lower, 0) || math.IsInf(upper, 0) {
+		// the step is too small for the bounds to count its multiples
+		return min
+	}
+	if s.ExclusiveMinimum && lower*step <= min {
+		lower++
+	}
+	if s.ExclusiveMaximum && upper*step >= max {
+		upper--
+	}
+	if lower > upper {
+		return round(lower*step, step)
+	}
+	if span := upper - lower; span < 1<<62 {
+		return round((lower+float64(r.Int63n(int64(span)+1)))*step, step)
+	}
+	// the wide bounds are picked in float space, which never overflows
+	f := r.Float64()
+	return round(math.Min(math.Floor(lower*(1-f)+upper*f), upper)*step, step)
+}
+
+// toInt rounds the number to an int, which is clamped to the range of int.
+func toInt(f float64) int {
+	switch f = math.Round(f); {
+	case math.IsNaN(f):
+		return 0
+	case f >= math.MaxInt:
+		return math.MaxInt
+	case f <= math.MinInt:
+		return math.MinInt
+	}
+	return int(f)
+}
+
+// integerStep returns the least integer which is a multiple of the step, e.g. 3 for 1.5.
+func integerStep(step float64) float64 {
+	for k := 1.0; k <= 1000; k++ {
+		if v := math.Round(k * step); v > 0 && math.Abs(k*step-v) < 1e-9*math.Max(1, v) {
+			return v
+		}
+	}
+	return math.Ceil(step)
+}
+
+// round removes the floating point noise of the multiple of the step,
+// which keeps at least two decimals and all the decimals of the step.
+func round(v, step float64) float64 {
+	p := math.Pow(10, math.Max(2, math.Ceil(-math.Log10(step))))
+	if r := math.Round(v*p) / p; !math.IsInf(r, 0) && !math.IsNaN(r) {
+		return r
+	}
+	return v
+}
+
+// rand returns the random source of the property, which is seeded by the generator seed,
+// the property name and the schema type, so that it is independent of the conversion order.
+func (g *ExampleGenerator) rand(s *Schema, name string) *rand.Rand {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%s|%s|%s|%s", name, s.Type, s.Format, s.Pattern)
+	return rand.New(rand.NewSource(g.seed ^ int64(h.Sum64())))
+}
+
+// typedValue parses the displayed value as the type of the schema.
+func typedValue(t ParameterType, v string) interface{} {
+	switch t {
+	case Integer:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+		f, _ := strconv.ParseFloat(v, 64)
+		return toInt(f)
+	case Number:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	case Boolean:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return v
+}
+
+// bounds returns the minimum and maximum of the schema, the missing ones are derived from the defaults.
+func bounds(s *Schema, min, max float64) (float64, float64) {
+	switch {
+	case s.Minimum != nil && s.Maximum != nil:
+		return *s.Minimum, *s.Maximum
+	case s.Minimum != nil:
+		return *s.Minimum, *s.Minimum + (max - min)
+	case s.Maximum != nil:
+		if min < *s.Maximum {
+			return min, *s.Maximum
+		}
+		return *s.Maximum - (max - min), *s.Maximum
+	}
+	return min, max
+}
+
+var exampleBaseTime = time.Date(2022, time.January, 1, 8, 0, 0, 0, time.UTC)
+
+var (
+	firstNames = []string{"Alice", "Bob", "Carol", "David", "Emma", "Frank"}
+	lastNames  = []string{"Smith", "Johnson", "Brown", "Taylor", "Wilson", "Clark"}
+	cities     = []string{"London", "Paris", "Berlin", "Tokyo", "Toronto", "Sydney"}
+	countries  = []string{"GB", "FR", "DE", "JP", "CA", "AU"}
+	words      = []string{"alpha", "bravo", "delta", "echo", "lima", "sierra", "tango"}
+	statuses   = []string{"active", "pending", "completed"}
+)
+
+// fitLength pads or truncates the string to the minLength and maxLength of the schema,
+// the string padded to a minLength over exampleMaxLength is shortened and ends with exampleEllipsis.
+func fitLength(s *Schema, v string) string {
+	runes := []rune(v)
+	if s.MinLength != nil && len(runes) < *s.MinLength {
+		n := *s.MinLength
+		shortened := n > exampleMaxLength
+		if shortened {
+			n = exampleMaxLength - len(exampleEllipsis)
+		}
+		if len(runes) == 0 {
+			runes = []rune("x")
+		}
+		for len(runes) < n {
+			runes = append(runes, runes...)
+		}
+		runes = runes[:n]
+		if shortened {
+			return string(runes) + exampleEllipsis
+		}
+	}
+	if s.MaxLength != nil && len(runes) > *s.MaxLength {
+		runes = runes[:*s.MaxLength]
+	}
+	return string(runes)
+}
+
+// stringExample returns the example of the string schema,
+// and whether it is generated from the pattern.
+func stringExample(s *Schema, name string, r *rand.Rand) (string, bool) {
+	if v, ok := stringByFormat(s.Format, name, r); ok {
+		return v, false
+	}
+	if s.Pattern != "" {
+		if v, ok := patternExample(s.Pattern, r); ok {
+			return v, true
+		}
+	}
+	if v, ok := stringByName(name, r); ok {
+		return v, false
+	}
+	return pick(r, words), false
+}
+
+func stringByFormat(format, name string, r *rand.Rand) (string, bool) {
+	switch strings.ToLower(format) {
+	case "date-time":
+		return randomTime(r).Format(time.RFC3339), true
+	case "date":
+		return randomTime(r).Format("2006-01-02"), true
+	case "time":
+		return randomTime(r).Format("15:04:05"), true
+	case "uuid":
+		return randomUUID(r), true
+	case "email":
+		return strings.ToLower(pick(r, firstNames)) + "@example.com", true
+	case "uri", "url":
+		return "https://example.com/" + pathName(name, r), true
+	case "hostname":
+		return "api.example.com", true
+	case "ipv4":
+		// the TEST-NET-1 block reserved for documentation
+		return fmt.Sprintf("192.0.2.%d", r.Intn(254)+1), true
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x", r.Intn(0xffff)+1), true
+	case "byte":
+		return base64.StdEncoding.EncodeToString([]byte(pick(r, words))), true
+	case "password":
+		return "********", true
+	}
+	return "", false
+}
+
+// stringByName guesses the value by the property name.
+func stringByName(name string, r *rand.Rand) (string, bool) {
+	n := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+	if n == "" {
+		return "", false
+	}
+	has := func(parts ...string) bool {
+		for _, part := range parts {
+			if strings.Contains(n, part) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("email", "mail"):
+		return stringByFormat("email", name, r)
+	case has("url", "uri", "link", "href", "website"):
+		return stringByFormat("uri", name, r)
+	case has("uuid", "guid"), n == "id", hasWordSuffix(name, "id"):
+		return stringByFormat("uuid", name, r)
+	case n == "ip", has("ipaddress", "ipaddr"):
+		return stringByFormat("ipv4", name, r)
+	case has("host", "domain"):
+		return stringByFormat("hostname", name, r)
+	case has("password", "secret"):
+		return stringByFormat("password", name, r)
+	case has("date", "time"), hasWordSuffix(name, "at"):
+		return stringByFormat("date-time", name, r)
+	case has("phone", "mobile"):
+		return fmt.Sprintf("+1-202-555-%04d", r.Intn(10000)), true
+	case has("firstname", "givenname"):
+		return pick(r, firstNames), true
+	case has("lastname", "surname", "familyname"):
+		return pick(r, lastNames), true
+	case has("username", "login", "nickname"):
+		return strings.ToLower(pick(r, firstNames)) + strconv.Itoa(r.Intn(100)), true
+	case has("name"):
+		return pick(r, firstNames) + " " + pick(r, lastNames), true
+	case has("city"):
+		return pick(r, cities), true
+	case has("country"):
+		return pick(r, countries), true
+	case has("address", "street"):
+		return fmt.Sprintf("%d Main Street", r.Intn(900)+100), true
+	case has("zip", "postcode", "postalcode"):
+		return fmt.Sprintf("%05d", r.Intn(100000)), true
+	case has("color", "colour"):
+		return fmt.Sprintf("#%06x", r.Intn(0x1000000)), true
+	case has("token", "hash", "key"):
+		return fmt.Sprintf("%016x", r.Uint64()), true
+	case has("status", "state"):
+		return pick(r, statuses), true
+	case has("description", "summary", "comment", "note", "message", "text"):
+		return "Lorem ipsum dolor sit amet.", true
+	case has("title"):
+		return "Sample " + pick(r, words), true
+	case has("version"):
+		return fmt.Sprintf("%d.%d.%d", r.Intn(3)+1, r.Intn(10), r.Intn(10)), true
+	}
+	return "", false
+}
+
+// hasWordSuffix reports whether the last word of the camelCase or snake_case name is the suffix,
+// e.g. "userId" and "created_at".
+func hasWordSuffix(name, suffix string) bool {
+	if len(name) <= len(suffix) {
+		return false
+	}
+	last := name[len(name)-len(suffix):]
+	if !strings.EqualFold(last, suffix) {
+		return false
+	}
+	prev := name[len(name)-len(suffix)-1]
+	return prev == '_' || prev == '-' || unicode.IsUpper(rune(last[0])) && unicode.IsLower(rune(prev))
+}
+
+func booleanByName(name string, r *rand.Rand) bool {
+	n := strings.ToLower(name)
+	for _, prefix := range []string{"is", "has", "can", "enable", "active", "allow"} {
+		if strings.HasPrefix(n, prefix) {
+			return true
+		}
+	}
+	return r.Intn(2) == 0
+}
+
+func randomTime(r *rand.Rand) time.Time {
+	return exampleBaseTime.Add(time.Duration(r.Intn(365*24*3600)) * time.Second)
+}
+
+// randomUUID returns a version 4 uuid.
+func randomUUID(r *rand.Rand) string {
+	b := make([]byte, 16)
+	_, _ = r.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func pathName(name string, r *rand.Rand) string {
+	if name == "" {
+		return pick(r, words)
+	}
+	return strings.ToLower(name)
+}
+
+func pick(r *rand.Rand, values []string) string {
+	return values[r.Intn(len(values))]
+}
+
+// maxPatternRepeat limits the repetitions of the unbounded pattern operators.
+const maxPatternRepeat = 3
+
+// patternExample generates a string matching the regular expression.
+func patternExample(pattern string, r *rand.Rand) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var b strings.Builder
+	writePattern(&b, re, r)
+	return b.String(), true
+}
+
+func writePattern(b *strings.Builder, re *syntax.Regexp, r *rand.Rand) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(classRune(re.Rune, r))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune(rune('a' + r.Intn(26)))
+	case syntax.OpCapture:
+		writePattern(b, re.Sub[0], r)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writePattern(b, sub, r)
+		}
+	case syntax.OpAlternate:
+		writePattern(b, re.Sub[r.Intn(len(re.Sub))], r)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, maxPatternRepeat
+		case syntax.OpPlus:
+			min, max = 1, maxPatternRepeat
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 || max > min+maxPatternRepeat {
+			max = min + maxPatternRepeat
+		}
+		n := min + r.Intn(max-min+1)
+		for i := 0; i < n; i++ {
+			writePattern(b, re.Sub[0], r)
+		}
+	}
+}
+
+// classRune picks a rune of the character class, the printable ascii runes are preferred.
+func classRune(ranges []rune, r *rand.Rand) rune {
+	var printable [][2]rune
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if lo < '!' {
+			lo = '!'
+		}
+		if hi > '~' {
+			hi = '~'
+		}
+		if lo <= hi {
+			printable = append(printable, [2]rune{lo, hi})
+		}
+	}
+	if len(printable) == 0 {
+		if len(ranges) == 0 {
+			return 'a'
+		}
+		if unicode.IsPrint(ranges[0]) {
+			return ranges[0]
+		}
+		return 'a'
+	}
+	pr := printable[r.Intn(len(printable))]
+	return pr[0] + rune(r.Intn(int(pr[1]-pr[0])+1))
+}
@@ -0,0 +1,297 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+func TestExampleDeclaredValues(t *testing.T) {
+	g := NewExampleGenerator(DefaultExampleSeed)
+	for _, c := range []struct {
+		schema *Schema
+		want   interface{}
+	}{
+		{schema: &Schema{Type: Integer, Example: "42", Default: 1, Enum: []string{"7"}}, want: 42},
+		{schema: &Schema{Type: String, Default: "cat", Enum: []string{"dog", "cat"}}, want: "cat"},
+		{schema: &Schema{Type: String, Enum: []string{"dog", "cat"}}, want: "dog"},
+		{schema: &Schema{Type: Boolean, Enum: []string{"false"}}, want: false},
+		{schema: &Schema{Type: String, Const: "fixed"}, want: "fixed"},
+		{schema: &Schema{OneOf: []*Schema{{Type: String, Example: "a"}, {Type: Integer, Example: "1"}}}, want: "a"},
+	} {
+		if v, ok := g.ConvertSchemaToValue(nil, c.schema); !ok || v != c.want {
+			t.Errorf("example of %+v = %#v, want %#v", c.schema, v, c.want)
+		}
+	}
+}
+
+func TestExampleFormatsAndNames(t *testing.T) {
+	isTime := func(v string) bool {
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	}
+	isEmail := func(v string) bool {
+		_, err := mail.ParseAddress(v)
+		return err == nil
+	}
+	object := &Schema{Type: Object, Properties: map[string]*Schema{
+		"when":      {Type: String, Format: "date-time"},
+		"ref":       {Type: String, Format: "uuid"},
+		"contact":   {Type: String, Format: "email"},
+		"createdAt": {Type: String},
+		"userId":    {Type: String},
+		"email":     {Type: String},
+		"isActive":  {Type: Boolean},
+	}}
+	checks := map[string]func(v interface{}) bool{
+		"when":      func(v interface{}) bool { s, _ := v.(string); return isTime(s) },
+		"ref":       func(v interface{}) bool { s, _ := v.(string); return uuidRegexp.MatchString(s) },
+		"contact":   func(v interface{}) bool { s, _ := v.(string); return isEmail(s) },
+		"createdAt": func(v interface{}) bool { s, _ := v.(string); return isTime(s) },
+		"userId":    func(v interface{}) bool { s, _ := v.(string); return uuidRegexp.MatchString(s) },
+		"email":     func(v interface{}) bool { s, _ := v.(string); return isEmail(s) },
+		"isActive":  func(v interface{}) bool { return v == true },
+	}
+
+	for seed := int64(1); seed <= 10; seed++ {
+		v, ok := NewExampleGenerator(seed).ConvertSchemaToValue(nil, object)
+		obj, _ := v.(map[string]interface{})
+		if !ok || len(obj) != len(checks) {
+			t.Fatalf("seed %d: example %#v, want %d properties", seed, v, len(checks))
+		}
+		for name, check := range checks {
+			if !check(obj[name]) {
+				t.Errorf("seed %d: unexpected %s %#v", seed, name, obj[name])
+			}
+		}
+	}
+}
+
+func TestExampleBounds(t *testing.T) {
+	min, max := 10.0, 12.0
+	schema := &Schema{Type: Integer, Minimum: &min, Maximum: &max}
+	exclusive := &Schema{Type: Integer, Minimum: &min, Maximum: &max, ExclusiveMinimum: true, ExclusiveMaximum: true}
+	for seed := int64(1); seed <= 20; seed++ {
+		g := NewExampleGenerator(seed)
+		if v, _ := g.ConvertSchemaToValue(nil, schema); v.(int) < 10 || v.(int) > 12 {
+			t.Errorf("seed %d: %v is out of [10, 12]", seed, v)
+		}
+		if v, _ := g.ConvertSchemaToValue(nil, exclusive); v != 11 {
+			t.Errorf("seed %d: %v is out of (10, 12)", seed, v)
+		}
+	}
+}
+
+func TestExampleDeterministic(t *testing.T) {
+	schema := &Schema{Type: Array, Items: &Schema{Type: Object, Properties: map[string]*Schema{
+		"name":  {Type: String},
+		"score": {Type: Number},
+		"code":  {Type: String, Pattern: "^[A-Z]{3}-[0-9]{2}$"},
+	}}}
+	first, _ := NewExampleGenerator(7).ConvertSchemaToValue(nil, schema)
+	again, _ := NewExampleGenerator(7).ConvertSchemaToValue(nil, schema)
+	if !reflect.DeepEqual(first, again) {
+		t.Errorf("seed 7 generates %#v and %#v", first, again)
+	}
+
+	items, _ := first.([]interface{})
+	if len(items) != 1 {
+		t.Fatalf("example = %#v, want one item", first)
+	}
+	code, _ := items[0].(map[string]interface{})["code"].(string)
+	if !regexp.MustCompile("^[A-Z]{3}-[0-9]{2}$").MatchString(code) {
+		t.Errorf("code %q does not match the pattern", code)
+	}
+}
+
+func TestDefinitionExamples(t *testing.T) {
+	schemas := map[string]*Schema{
+		"Pet": {Type: Object, Properties: map[string]*Schema{
+			"name":   {Type: String, Example: "rex"},
+			"tag":    {Ref: "#/definitions/Tag"},
+			"parent": {Ref: "#/definitions/Pet"},
+		}},
+		"Tag": {Type: String, Example: "dog"},
+	}
+	set, err := NewExampleGenerator(DefaultExampleSeed).ConvertSchemaToMap(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the recursive parent is left out of the json example
+	want := map[string]interface{}{
+		"name": "rex",
+		"tag":  "dog",
+	}
+	if pet := set["#/definitions/Pet"]; !reflect.DeepEqual(pet, want) {
+		t.Errorf("pet = %#v, want %#v", pet, want)
+	}
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestExampleCaps(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *Schema
+		check  func(t *testing.T, v interface{})
+	}{
+		{
+			name:   "huge minLength",
+			schema: &Schema{Type: String, MinLength: intPtr(200000000)},
+			check: func(t *testing.T, v interface{}) {
+				s, _ := v.(string)
+				if n := utf8.RuneCountInString(s); n != exampleMaxLength {
+					t.Errorf("length = %d, want %d", n, exampleMaxLength)
+				}
+				if !strings.HasSuffix(s, exampleEllipsis) {
+					t.Errorf("%q is not marked as shortened", s)
+				}
+			},
+		},
+		{
+			name:   "small minLength",
+			schema: &Schema{Type: String, MinLength: intPtr(10)},
+			check: func(t *testing.T, v interface{}) {
+				s, _ := v.(string)
+				if n := utf8.RuneCountInString(s); n != 10 || strings.HasSuffix(s, exampleEllipsis) {
+					t.Errorf("got %q, want 10 characters without the marker", s)
+				}
+			},
+		},
+		{
+			name:   "huge minItems",
+			schema: &Schema{Type: Array, MinItems: intPtr(20000000), Items: &Schema{Type: Integer}},
+			check: func(t *testing.T, v interface{}) {
+				items, _ := v.([]interface{})
+				if len(items) != exampleMaxItems+1 || items[len(items)-1] != exampleEllipsis {
+					t.Errorf("got %v, want %d items and the marker", items, exampleMaxItems)
+				}
+			},
+		},
+		{
+			name:   "small minItems",
+			schema: &Schema{Type: Array, MinItems: intPtr(2), Items: &Schema{Type: Integer}},
+			check: func(t *testing.T, v interface{}) {
+				if items, _ := v.([]interface{}); len(items) != 2 {
+					t.Errorf("got %v, want 2 items", items)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan interface{}, 1)
+			go func() {
+				v, _ := NewExampleGenerator(1).ConvertSchemaToValue(nil, tt.schema)
+				done <- v
+			}()
+			select {
+			case v := <-done:
+				tt.check(t, v)
+			case <-time.After(5 * time.Second):
+				t.Fatal("example generation did not finish")
+			}
+		})
+	}
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestNumberAndPatternExamples(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *Schema
+		check  func(v interface{}) bool
+	}{
+		{
+			name:   "integer multipleOf a fraction",
+			schema: &Schema{Type: Integer, MultipleOf: floatPtr(1.5), Minimum: floatPtr(1), Maximum: floatPtr(100)},
+			check: func(v interface{}) bool {
+				i, ok := v.(int)
+				return ok && i%3 == 0 && i >= 1 && i <= 100
+			},
+		},
+		{
+			name:   "integer multipleOf below one",
+			schema: &Schema{Type: Integer, MultipleOf: floatPtr(0.25)},
+			check: func(v interface{}) bool {
+				_, ok := v.(int)
+				return ok
+			},
+		},
+		{
+			name:   "wide integer bounds",
+			schema: &Schema{Type: Integer, Minimum: floatPtr(0), Maximum: floatPtr(9223372036854775807)},
+			check: func(v interface{}) bool {
+				i, ok := v.(int)
+				return ok && i >= 0
+			},
+		},
+		{
+			name:   "wide number bounds",
+			schema: &Schema{Type: Number, Minimum: floatPtr(-1e308), Maximum: floatPtr(1e308)},
+			check: func(v interface{}) bool {
+				f, ok := v.(float64)
+				return ok && f >= -1e308 && f <= 1e308
+			},
+		},
+		{
+			name:   "pattern with maxLength",
+			schema: &Schema{Type: String, Pattern: "^[a-z]{5}-[0-9]{3}$", MaxLength: intPtr(4)},
+			check: func(v interface{}) bool {
+				s, _ := v.(string)
+				return regexp.MustCompile("^[a-z]{5}-[0-9]{3}$").MatchString(s)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := int64(1); seed <= 20; seed++ {
+				v, ok := NewExampleGenerator(seed).ConvertSchemaToValue(nil, tt.schema)
+				if !ok || !tt.check(v) {
+					t.Fatalf("seed %d: unexpected example %#v", seed, v)
+				}
+			}
+		})
+	}
+}
+
+func TestMapExamples(t *testing.T) {
+	set, err := NewExampleGenerator(DefaultExampleSeed).ConvertSchemaToMap(mapDefinitions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]interface{}{
+		"Labels": map[string]interface{}{"name": "env", "key": map[string]interface{}{"value": "prod"}},
+		"Tags":   map[string]interface{}{"key": "dog"},
+		// the values of a free-form map can be anything
+		"Meta":   map[string]interface{}{"key": "value"},
+		"Closed": map[string]interface{}{"id": 1},
+		// the declared property wins over the map entry
+		"Keyed": map[string]interface{}{"key": "declared"},
+	} {
+		if got := set[DefinitionRef(name)]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s example = %#v, want %#v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity is the level of a lint rule, the rules with SeverityOff are disabled.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+	SeverityOff     Severity = "off"
+)
+
+// DefaultErrorDefinition is the definition the 4xx responses should use by default.
+const DefaultErrorDefinition = "Error"
+
+// LintRule is a style rule checked against the API.
+type LintRule struct {
+	Name        string
+	Description string
+
+	// Severity is the default severity of the rule.
+	Severity Severity
+
+	check func(l *linter)
+}
+
+// LintConfig configures the lint rules.
+type LintConfig struct {
+	// Rules overrides the severities of the rules by name, use SeverityOff to disable a rule.
+	Rules map[string]Severity `json:"rules,omitempty" yaml:"rules,omitempty"`
+
+	// ErrorDefinition is the definition the 4xx responses should use, DefaultErrorDefinition by default.
+	ErrorDefinition string `json:"errorDefinition,omitempty" yaml:"errorDefinition,omitempty"`
+}
+
+// Validate checks the rule names and the severities of the config.
+func (c *LintConfig) Validate() error {
+	for name, severity := range c.Rules {
+		if _, ok := LintRuleByName(name); !ok {
+			return fmt.Errorf("unknown lint rule: %s", name)
+		}
+		switch severity {
+		case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		default:
+			return fmt.Errorf("unknown severity %s of lint rule %s", severity, name)
+		}
+	}
+	return nil
+}
+
+// severity returns the configured severity of the rule.
+func (c *LintConfig) severity(rule LintRule) Severity {
+	if c != nil {
+		if severity, ok := c.Rules[rule.Name]; ok {
+			return severity
+		}
+	}
+	return rule.Severity
+}
+
+// LintResult is a violation of a lint rule.
+type LintResult struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+
+	// Pointer is the json pointer of the violating value in the swagger document.
+	Pointer string `json:"pointer"`
+
+	// Line is the line of the pointer in the source, 0 if it is unknown.
+	Line int `json:"line,omitempty"`
+
+	Message string `json:"message"`
+}
+
+var lintRules = []LintRule{
+	{
+		Name:        "operation-summary",
+		Description: "Every operation has a summary.",
+		Severity:    SeverityError,
+		check:       lintOperationSummary,
+	},
+	{
+		Name:        "operation-operation-id",
+		Description: "Every operation has an operationId.",
+		Severity:    SeverityError,
+		check:       lintOperationID,
+	},
+	{
+		Name:        "path-kebab-case",
+		Description: "The path segments are kebab-case, e.g. /user-groups/{groupId}.",
+		Severity:    SeverityWarning,
+		check:       lintPathKebabCase,
+	},
+	{
+		Name:        "error-response-schema",
+		Description: "The 4xx responses use the shared error definition.",
+		Severity:    SeverityError,
+		check:       lintErrorResponseSchema,
+	},
+	{
+		Name:        "description-non-empty",
+		Description: "The tags, parameters, responses and definitions have descriptions.",
+		Severity:    SeverityWarning,
+		check:       lintDescriptions,
+	},
+}
+
+// LintRules returns the built-in lint rules.
+func LintRules() []LintRule {
+	out := make([]LintRule, len(lintRules))
+	copy(out, lintRules)
+	return out
+}
+
+// LintRuleByName returns the built-in lint rule with the name.
+func LintRuleByName(name string) (LintRule, bool) {
+	for _, rule := range lintRules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return LintRule{}, false
+}
+
+// Lint checks the API against the enabled lint rules, the results are grouped by rule.
+func Lint(api *API, cfg *LintConfig) []LintResult {
+	l := &linter{api: api, errorDefinition: DefaultErrorDefinition}
+	if cfg != nil && cfg.ErrorDefinition != "" {
+		l.errorDefinition = cfg.ErrorDefinition
+	}
+	for _, rule := range lintRules {
+		severity := cfg.severity(rule)
+		if severity == SeverityOff {
+			continue
+		}
+		l.rule, l.severity = rule.Name, severity
+		rule.check(l)
+	}
+	return l.results
+}
+
+type linter struct {
+	api             *API
+	errorDefinition string
+
+	// the rule being checked
+	rule     string
+	severity Severity
+
+	results []LintResult
+}
+
+func (l *linter) report(pointer, format string, args ...interface{}) {
+	l.results = append(l.results, LintResult{
+		Rule:     l.rule,
+		Severity: l.severity,
+		Pointer:  pointer,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func lintOperationSummary(l *linter) {
+	for _, e := range l.api.AllEndpoints() {
+		if strings.TrimSpace(e.Summary) == "" {
+			l.report(operationPointer(e), "operation %s %s has no summary", e.Method, e.Path)
+		}
+	}
+}
+
+func lintOperationID(l *linter) {
+	for _, e := range l.api.AllEndpoints() {
+		if strings.TrimSpace(e.OperationID) == "" {
+			l.report(operationPointer(e), "operation %s %s has no operationId", e.Method, e.Path)
+		}
+	}
+}
+
+var kebabCaseRegexp = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func lintPathKebabCase(l *linter) {
+	for _, p := range sortedPaths(l.api) {
+		for _, segment := range strings.Split(p, "/") {
+			// the path templates are parameter names, not part of the style
+			if segment == "" || strings.Contains(segment, "{") {
+				continue
+			}
+			if !kebabCaseRegexp.MatchString(segment) {
+				l.report(JSONPointer("paths", p), "path segment %q of %s is not kebab-case", segment, p)
+				break
+			}
+		}
+	}
+}
+
+func lintErrorResponseSchema(l *linter) {
+	for _, e := range l.api.AllEndpoints() {
+		for _, code := range sortedResponseCodes(e.Responses) {
+			if !strings.HasPrefix(code, "4") {
+				continue
+			}
+			res := e.Responses[code]
+			if res == nil {
+				continue
+			}
+			pointer := JSONPointer("paths", e.Path, strings.ToLower(e.Method), "responses", code)
+			if res.Schema == nil {
+				l.report(pointer, "response %s of %s %s has no schema, expect the %s definition", code, e.Method, e.Path, l.errorDefinition)
+				continue
+			}
+			if name, _ := DefinitionName(res.Schema.Ref); name != l.errorDefinition {
+				l.report(pointer+"/schema", "response %s of %s %s does not use the %s definition", code, e.Method, e.Path, l.errorDefinition)
+			}
+		}
+	}
+}
+
+func lintDescriptions(l *linter) {
+	for i, tag := range l.api.Tags {
+		if strings.TrimSpace(tag.Description) == "" {
+			l.report(JSONPointer("tags", strconv.Itoa(i)), "tag %s has no description", tag.Name)
+		}
+	}
+	for _, e := range l.api.AllEndpoints() {
+		op := operationPointer(e)
+		for i, param := range e.Parameters {
+			if strings.TrimSpace(param.Description) == "" {
+				pointer := param.pointer
+				if pointer == "" {
+					pointer = op + JSONPointer("parameters", strconv.Itoa(i))
+				}
+				l.report(pointer, "parameter %s of %s %s has no description", param.Name, e.Method, e.Path)
+			}
+		}
+		for _, code := range sortedResponseCodes(e.Responses) {
+			res := e.Responses[code]
+			if res != nil && strings.TrimSpace(res.Description) == "" {
+				l.report(op+JSONPointer("responses", code), "response %s of %s %s has no description", code, e.Method, e.Path)
+			}
+		}
+	}
+	for _, name := range sortedSchemaNames(l.api.Definitions) {
+		s := l.api.Definitions[name]
+		if s != nil && strings.TrimSpace(s.Description) == "" && strings.TrimSpace(s.Title) == "" {
+			l.report(JSONPointer("definitions", name), "definition %s has no description", name)
+		}
+	}
+}
+
+func operationPointer(e *Endpoint) string {
+	return JSONPointer("paths", e.Path, strings.ToLower(e.Method))
+}
+
+func sortedPaths(api *API) []string {
+	paths := make([]string, 0, len(api.Paths))
+	for p := range api.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// JSONPointer returns the json pointer of the reference tokens, e.g. `/paths/~1pets/get`.
+func JSONPointer(tokens ...string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(pointerEscaper.Replace(token))
+	}
+	return b.String()
+}
@@ -0,0 +1,474 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Service is an API combined into a merged document.
+type Service struct {
+	// Name identifies the service, it namespaces the conflicting definitions
+	// and groups the operations of the service without tags.
+	Name string
+
+	// Prefix is prepended to the paths of the service,
+	// the base path of the API (or the path of its first server) is used by default.
+	Prefix string
+
+	API *API
+}
+
+// MergeOption defines the options of merging services.
+type MergeOption struct {
+	// Info is the metadata of the merged document.
+	Info Info
+
+	// GroupByService groups the operations by service instead of by their own tags.
+	GroupByService bool
+}
+
+// Merge combines the services into one API.
+//
+// The paths of each service are prefixed by its prefix or base path,
+// a path defined by more than one service is prefixed by "/<service>" in each of them.
+// The definitions and security schemes defined by more than one service are shared if they are identical,
+// otherwise they are namespaced as "<service>.<name>" and the references are rewritten.
+// The tags with the same name are merged into one.
+//
+// The definitions and operations of the services are reused and modified in place,
+// so the services should not be used after merging.
+func Merge(opt MergeOption, services ...Service) (*API, error) {
+	names := make(map[string]struct{}, len(services))
+	for _, svc := range services {
+		if svc.Name == "" {
+			return nil, fmt.Errorf("service name is required")
+		}
+		if _, ok := names[svc.Name]; ok {
+			return nil, fmt.Errorf("duplicate service name: %s", svc.Name)
+		}
+		names[svc.Name] = struct{}{}
+		if svc.API == nil {
+			return nil, fmt.Errorf("service %s has no api", svc.Name)
+		}
+	}
+
+	prefixes := servicePrefixes(services)
+	m := &merger{
+		opt:         opt,
+		services:    services,
+		definitions: definitionRenames(services),
+		securities:  securityRenames(services),
+		prefixes:    prefixes,
+		sharedPaths: sharedPaths(services, prefixes),
+		tagIndex:    make(map[string]int),
+		pathOwners:  make(map[string]string),
+		api: &API{
+			Swagger:             "2.0",
+			Info:                opt.Info,
+			Paths:               make(map[string]*Endpoints),
+			Definitions:         make(map[string]*Schema),
+			SecurityDefinitions: make(map[string]*SecurityScheme),
+		},
+	}
+	for i := range services {
+		if err := m.merge(i); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.api.TransformSchemas(); err != nil {
+		return nil, err
+	}
+	return m.api, nil
+}
+
+type merger struct {
+	opt      MergeOption
+	services []Service
+	api      *API
+
+	// the renamed definitions and security schemes of each service
+	definitions []map[string]string
+	securities  []map[string]string
+
+	// the path prefix of each service, and the prefixed paths defined by more than one service
+	prefixes    []string
+	sharedPaths map[string]bool
+
+	tagIndex   map[string]int
+	pathOwners map[string]string
+}
+
+func (m *merger) merge(i int) error {
+	svc := m.services[i]
+	api := svc.API
+	visited := make(map[*Schema]struct{})
+	rewrite := func(s *Schema) {
+		rewriteRefs(s, m.definitions[i], visited)
+	}
+
+	for _, name := range sortedSchemaNames(api.Definitions) {
+		target := renamed(m.definitions[i], name)
+		if _, ok := m.api.Definitions[target]; ok {
+			// shared with a previous service
+			continue
+		}
+		s := api.Definitions[name]
+		rewrite(s)
+		m.api.Definitions[target] = s
+	}
+	for name, scheme := range api.SecurityDefinitions {
+		target := renamed(m.securities[i], name)
+		if _, ok := m.api.SecurityDefinitions[target]; !ok {
+			m.api.SecurityDefinitions[target] = scheme
+		}
+	}
+
+	if m.opt.GroupByService {
+		m.addTag(Tag{Name: svc.Name, Description: serviceDescription(api)})
+	} else {
+		for _, tag := range api.Tags {
+			m.addTag(tag)
+		}
+	}
+
+	paths := make([]string, 0, len(api.Paths))
+	for p := range api.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		es := api.Paths[p]
+		if es == nil {
+			continue
+		}
+		target := m.prefixes[i] + p
+		if m.sharedPaths[target] {
+			target = "/" + svc.Name + target
+		}
+		if owner, ok := m.pathOwners[target]; ok {
+			return fmt.Errorf("path %s is defined by both service %s and %s, set a different prefix for them", target, owner, svc.Name)
+		}
+		m.pathOwners[target] = svc.Name
+		m.api.Paths[target] = es
+
+		for _, e := range es.All() {
+			for _, param := range e.Parameters {
+				if param.Schema != nil {
+					rewrite(param.Schema)
+				}
+				if param.Items != nil {
+					rewrite(param.Items)
+				}
+				for _, c := range param.Contents {
+					if c.Schema != nil {
+						rewrite(c.Schema)
+					}
+				}
+			}
+			for _, res := range e.Responses {
+				if res == nil {
+					continue
+				}
+				if res.Schema != nil {
+					rewrite(res.Schema)
+				}
+				for _, c := range res.Contents {
+					if c.Schema != nil {
+						rewrite(c.Schema)
+					}
+				}
+			}
+			if e.Security == nil && api.Security != nil {
+				e.Security = &SecurityRequirement{
+					Requirements:    api.Security.Requirements,
+					DisableSecurity: api.Security.DisableSecurity,
+				}
+			}
+			if e.Security != nil {
+				e.Security.Requirements = renameRequirements(e.Security.Requirements, m.securities[i])
+			}
+
+			if m.opt.GroupByService {
+				e.Tags = []string{svc.Name}
+			} else if len(e.Tags) == 0 {
+				e.Tags = []string{svc.Name}
+				m.addTag(Tag{Name: svc.Name, Description: serviceDescription(api)})
+			}
+		}
+	}
+
+	for _, s := range serviceServers(api) {
+		m.addServer(Server{URL: s, Description: svc.Name})
+	}
+	return nil
+}
+
+// sharedPaths returns the prefixed paths defined by more than one service.
+func sharedPaths(services []Service, prefixes []string) map[string]bool {
+	owners := make(map[string]int)
+	for i, svc := range services {
+		for p, es := range svc.API.Paths {
+			if es != nil {
+				owners[prefixes[i]+p]++
+			}
+		}
+	}
+	shared := make(map[string]bool)
+	for p, n := range owners {
+		if n > 1 {
+			shared[p] = true
+		}
+	}
+	return shared
+}
+
+// servicePrefixes returns the path prefix of each service, which is its base path by default.
+func servicePrefixes(services []Service) []string {
+	out := make([]string, len(services))
+	for i, svc := range services {
+		prefix := svc.Prefix
+		if prefix == "" {
+			prefix = basePath(svc.API)
+		}
+		out[i] = strings.TrimSuffix(prefix, "/")
+	}
+	return out
+}
+
+// addTag declares the tag, the tags with the same name are merged.
+func (m *merger) addTag(tag Tag) {
+	i, ok := m.tagIndex[tag.Name]
+	if !ok {
+		m.tagIndex[tag.Name] = len(m.api.Tags)
+		m.api.Tags = append(m.api.Tags, tag)
+		return
+	}
+	current := &m.api.Tags[i]
+	if current.Description == "" {
+		current.Description = tag.Description
+	}
+	if current.Docs == nil {
+		current.Docs = tag.Docs
+	}
+}
+
+// addServer adds the server, the services sharing the same server are listed in its description.
+func (m *merger) addServer(server Server) {
+	for i := range m.api.Servers {
+		current := &m.api.Servers[i]
+		if current.URL == server.URL {
+			current.Description += ", " + server.Description
+			return
+		}
+	}
+	m.api.Servers = append(m.api.Servers, server)
+}
+
+// definitionRenames returns the renamed definitions of each service.
+// A definition defined by several services is shared if all of them are identical
+// and none of the definitions they refer to is renamed, otherwise it is namespaced by service.
+func definitionRenames(services []Service) []map[string]string {
+	renames := make([]map[string]string, len(services))
+	owners := make(map[string][]int)
+	for i, svc := range services {
+		renames[i] = make(map[string]string)
+		for name := range svc.API.Definitions {
+			owners[name] = append(owners[name], i)
+		}
+	}
+
+	// renaming a definition changes the definitions referring to it,
+	// so check again until nothing is renamed.
+	for changed := true; changed; {
+		changed = false
+		for _, name := range sortedOwnerNames(owners) {
+			idx := owners[name]
+			if len(idx) < 2 {
+				continue
+			}
+			if _, ok := renames[idx[0]][name]; ok {
+				continue
+			}
+			if sameDefinition(services, renames, name, idx) {
+				continue
+			}
+			for _, i := range idx {
+				renames[i][name] = services[i].Name + "." + name
+			}
+			changed = true
+		}
+	}
+	return renames
+}
+
+func sameDefinition(services []Service, renames []map[string]string, name string, idx []int) bool {
+	first := services[idx[0]].API.Definitions[name]
+	for _, i := range idx[1:] {
+		if !reflect.DeepEqual(first, services[i].API.Definitions[name]) {
+			return false
+		}
+	}
+	if first == nil {
+		return true
+	}
+	for _, ref := range first.Refs() {
+		refName, ok := DefinitionName(ref)
+		if !ok {
+			continue
+		}
+		for _, i := range idx {
+			if _, ok := renames[i][refName]; ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// securityRenames returns the renamed security schemes of each service,
+// a security scheme defined differently by several services is namespaced by service.
+func securityRenames(services []Service) []map[string]string {
+	renames := make([]map[string]string, len(services))
+	owners := make(map[string][]int)
+	for i, svc := range services {
+		renames[i] = make(map[string]string)
+		for name := range svc.API.SecurityDefinitions {
+			owners[name] = append(owners[name], i)
+		}
+	}
+	for name, idx := range owners {
+		first := services[idx[0]].API.SecurityDefinitions[name]
+		same := true
+		for _, i := range idx[1:] {
+			if !reflect.DeepEqual(first, services[i].API.SecurityDefinitions[name]) {
+				same = false
+				break
+			}
+		}
+		if same {
+			continue
+		}
+		for _, i := range idx {
+			renames[i][name] = services[i].Name + "." + name
+		}
+	}
+	return renames
+}
+
+func renamed(renames map[string]string, name string) string {
+	if v, ok := renames[name]; ok {
+		return v
+	}
+	return name
+}
+
+// rewriteRefs rewrites the references to the renamed definitions in place,
+// the visited schemas are skipped since they may be shared by several operations.
+func rewriteRefs(s *Schema, renames map[string]string, visited map[*Schema]struct{}) {
+	if s == nil {
+		return
+	}
+	if _, ok := visited[s]; ok {
+		return
+	}
+	visited[s] = struct{}{}
+	if name, ok := DefinitionName(s.Ref); ok {
+		if target, ok := renames[name]; ok {
+			s.Ref = DefinitionRef(target)
+		}
+	}
+	for _, v := range s.subSchemas() {
+		rewriteRefs(v, renames, visited)
+	}
+}
+
+func renameRequirements(requirements []map[string][]string, renames map[string]string) []map[string][]string {
+	if len(renames) == 0 {
+		return requirements
+	}
+	out := make([]map[string][]string, 0, len(requirements))
+	for _, requirement := range requirements {
+		r := make(map[string][]string, len(requirement))
+		for name, scopes := range requirement {
+			r[renamed(renames, name)] = scopes
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// basePath returns the base path of the api, which is the path of the first server for OpenAPI 3.x.
+func basePath(api *API) string {
+	if api.BasePath != "" || len(api.Servers) == 0 {
+		return api.BasePath
+	}
+	u, err := url.Parse(api.Servers[0].Address())
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// serviceServers returns the addresses of the api without the base path.
+func serviceServers(api *API) []string {
+	var out []string
+	if len(api.Servers) > 0 {
+		for _, s := range api.Servers {
+			u, err := url.Parse(s.Address())
+			if err != nil || u.Host == "" {
+				continue
+			}
+			out = append(out, u.Scheme+"://"+u.Host)
+		}
+		return out
+	}
+	if api.Host == "" {
+		return nil
+	}
+	for _, scheme := range api.Schemes {
+		out = append(out, scheme+"://"+api.Host)
+	}
+	return out
+}
+
+func serviceDescription(api *API) string {
+	if api.Info.Description != "" {
+		return api.Info.Description
+	}
+	return api.Info.Title
+}
+
+func sortedSchemaNames(schemas map[string]*Schema) []string {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedOwnerNames(owners map[string][]int) []string {
+	names := make([]string, 0, len(owners))
+	for name := range owners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,198 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// mergeService returns a service with its own Pet, an identical Error,
+// and an Owner referring to the Pet, on /v1 with a shared /health path.
+// The items of a parameter refer to the Pet as well.
+func mergeService(name string, pet *Schema, tag Tag) Service {
+	return Service{
+		Name: name,
+		API: &API{
+			Info:     Info{Title: name + " api"},
+			Host:     name + ".example.com",
+			Schemes:  []string{"https"},
+			BasePath: "/v1",
+			Tags:     []Tag{tag},
+			Paths: map[string]*Endpoints{
+				"/" + name: {Get: &Endpoint{
+					Tags:       []string{tag.Name},
+					Parameters: []Parameter{{Name: "pets", In: "query", Type: Array, Items: &Schema{Ref: "#/definitions/Pet"}}},
+					Responses: map[string]*Response{
+						"200":     {Schema: &Schema{Type: Array, Items: &Schema{Ref: "#/definitions/Owner"}}},
+						"default": {Schema: &Schema{Ref: "#/definitions/Error"}},
+					},
+				}},
+				"/health": {Get: &Endpoint{
+					Responses: map[string]*Response{"200": {Description: "ok"}},
+				}},
+			},
+			Definitions: map[string]*Schema{
+				"Pet":   pet,
+				"Error": {Type: Object, Properties: map[string]*Schema{"code": {Type: Integer}}},
+				"Owner": {Type: Object, Properties: map[string]*Schema{"pet": {Ref: "#/definitions/Pet"}}},
+			},
+		},
+	}
+}
+
+func TestMerge(t *testing.T) {
+	api, err := Merge(MergeOption{Info: Info{Title: "merged"}},
+		mergeService("pets", &Schema{Type: Object, Properties: map[string]*Schema{"name": {Type: String}}}, Tag{Name: "animals"}),
+		mergeService("users", &Schema{Type: Object, Properties: map[string]*Schema{"id": {Type: Integer}}}, Tag{Name: "animals", Description: "the animals"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the Owner referring to the colliding Pet is namespaced as well, the identical Error is shared
+	var definitions []string
+	for name := range api.Definitions {
+		definitions = append(definitions, name)
+	}
+	sort.Strings(definitions)
+	if want := []string{"Error", "pets.Owner", "pets.Pet", "users.Owner", "users.Pet"}; !reflect.DeepEqual(definitions, want) {
+		t.Errorf("definitions = %v, want %v", definitions, want)
+	}
+	for _, svc := range []string{"pets", "users"} {
+		if ref := api.Definitions[svc+".Owner"].Properties["pet"].Ref; ref != "#/definitions/"+svc+".Pet" {
+			t.Errorf("%s.Owner refers to %s, want %s.Pet", svc, ref, svc)
+		}
+	}
+
+	// the base path prefixes the paths, the shared /health is prefixed by the service names
+	var paths []string
+	for p := range api.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	if want := []string{"/pets/v1/health", "/users/v1/health", "/v1/pets", "/v1/users"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	for _, svc := range []string{"pets", "users"} {
+		responses := api.Paths["/v1/"+svc].Get.Responses
+		if ref := responses["200"].Schema.Items.Ref; ref != "#/definitions/"+svc+".Owner" {
+			t.Errorf("%s response refers to %s, want %s.Owner", svc, ref, svc)
+		}
+		if ref := api.Paths["/v1/"+svc].Get.Parameters[0].Items.Ref; ref != "#/definitions/"+svc+".Pet" {
+			t.Errorf("%s parameter items refer to %s, want %s.Pet", svc, ref, svc)
+		}
+		if ref := responses["default"].Schema.Ref; ref != "#/definitions/Error" {
+			t.Errorf("%s error response refers to %s, want the shared Error", svc, ref)
+		}
+		if tags := api.Paths["/"+svc+"/v1/health"].Get.Tags; !reflect.DeepEqual(tags, []string{svc}) {
+			t.Errorf("%s health tags = %v, want the service", svc, tags)
+		}
+	}
+
+	// the tags of the same name are merged, the operations without tags are grouped by service
+	wantTags := []Tag{
+		{Name: "animals", Description: "the animals"},
+		{Name: "pets", Description: "pets api"},
+		{Name: "users", Description: "users api"},
+	}
+	if !reflect.DeepEqual(api.Tags, wantTags) {
+		t.Errorf("tags = %+v, want %+v", api.Tags, wantTags)
+	}
+	wantServers := []Server{
+		{URL: "https://pets.example.com", Description: "pets"},
+		{URL: "https://users.example.com", Description: "users"},
+	}
+	if !reflect.DeepEqual(api.Servers, wantServers) {
+		t.Errorf("servers = %+v, want %+v", api.Servers, wantServers)
+	}
+}
+
+func TestMergePrefixAndGroups(t *testing.T) {
+	pet := &Schema{Type: Object, Properties: map[string]*Schema{"name": {Type: String}}}
+	pets := mergeService("pets", pet, Tag{Name: "animals"})
+	pets.Prefix = "/pet-store/"
+	users := mergeService("users", pet, Tag{Name: "animals"})
+	api, err := Merge(MergeOption{GroupByService: true}, pets, users)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the identical definitions are all shared
+	if _, ok := api.Definitions["Pet"]; !ok || len(api.Definitions) != 3 {
+		t.Errorf("definitions = %v, want the shared Pet, Owner and Error", api.Definitions)
+	}
+	for p, tag := range map[string]string{
+		"/pet-store/pets":   "pets",
+		"/pet-store/health": "pets",
+		"/v1/users":         "users",
+		"/v1/health":        "users",
+	} {
+		es, ok := api.Paths[p]
+		if !ok {
+			t.Errorf("path %s is not merged", p)
+			continue
+		}
+		if !reflect.DeepEqual(es.Get.Tags, []string{tag}) {
+			t.Errorf("%s tags = %v, want %s", p, es.Get.Tags, tag)
+		}
+	}
+	var tags []string
+	for _, tag := range api.Tags {
+		tags = append(tags, tag.Name)
+	}
+	if want := []string{"pets", "users"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	pet := &Schema{Type: String}
+	conflict := mergeService("a", pet, Tag{Name: "a"})
+	// the shared /health of a is prefixed to /a/health, which a already defines
+	conflict.API.Paths["/a/health"] = conflict.API.Paths["/health"]
+	conflict.API.BasePath = ""
+	other := mergeService("b", pet, Tag{Name: "b"})
+	other.API.BasePath = ""
+
+	cases := map[string]struct {
+		services []Service
+		want     string
+	}{
+		"no name": {
+			services: []Service{{API: &API{}}},
+			want:     "service name is required",
+		},
+		"duplicate name": {
+			services: []Service{{Name: "a", API: &API{}}, {Name: "a", API: &API{}}},
+			want:     "duplicate service name: a",
+		},
+		"no api": {
+			services: []Service{{Name: "a"}},
+			want:     "service a has no api",
+		},
+		"path conflict": {
+			services: []Service{conflict, other},
+			want:     "path /a/health is defined by both service a and a, set a different prefix for them",
+		},
+	}
+	for name, c := range cases {
+		_, err := Merge(MergeOption{}, c.services...)
+		if err == nil || err.Error() != c.want {
+			t.Errorf("%s: error = %v, want %s", name, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,614 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	definitionsPrefix      = "#/definitions/"
+	parametersPrefix       = "#/parameters/"
+	responsesPrefix        = "#/responses/"
+	componentSchemasPrefix = "#/components/schemas/"
+	localDefsPrefix        = "#/$defs/"
+)
+
+// OpenAPI is the root document object of the OpenAPI 3.x specification.
+// It is only used as an input format and is converted into an API
+// before rendering, so that all templates work on a single model.
+type OpenAPI struct {
+	// Required. The semantic version number of the OpenAPI Specification
+	// version that the document uses, e.g. "3.0.3".
+	OpenAPI string `json:"openapi"`
+
+	// Required. Provides metadata about the API.
+	Info Info `json:"info"`
+
+	// An array of Server Objects, which provide connectivity information to a target server.
+	Servers []Server `json:"servers,omitempty"`
+
+	// Required. The available paths and operations for the API.
+	Paths map[string]*PathItem `json:"paths,omitempty"`
+
+	// An element to hold various schemas for the specification.
+	Components *Components `json:"components,omitempty"`
+
+	// A declaration of which security mechanisms can be used across the API.
+	Security *SecurityRequirement `json:"security,omitempty"`
+
+	// A list of tags used by the specification with additional metadata.
+	Tags []Tag `json:"tags,omitempty"`
+}
+
+// Server represents a server from the openapi definition.
+type Server struct {
+	URL         string                    `json:"url"`
+	Description string                    `json:"description,omitempty"`
+	Variables   map[string]ServerVariable `json:"variables,omitempty"`
+}
+
+// Address returns the server url with all variables replaced by their default values.
+func (s Server) Address() string {
+	addr := s.URL
+	for name, v := range s.Variables {
+		addr = strings.ReplaceAll(addr, "{"+name+"}", v.Default)
+	}
+	return addr
+}
+
+// ServerVariable represents a variable for server URL template substitution.
+type ServerVariable struct {
+	Enum        []string `json:"enum,omitempty"`
+	Default     string   `json:"default"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Components holds a set of reusable objects for different aspects of the openapi definition.
+type Components struct {
+	Schemas         map[string]*Schema                `json:"schemas,omitempty"`
+	Responses       map[string]*OpenAPIResponse       `json:"responses,omitempty"`
+	Parameters      map[string]*OpenAPIParameter      `json:"parameters,omitempty"`
+	RequestBodies   map[string]*RequestBody           `json:"requestBodies,omitempty"`
+	Headers         map[string]*OpenAPIHeader         `json:"headers,omitempty"`
+	SecuritySchemes map[string]*OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// PathItem describes the operations available on a single path.
+type PathItem struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Summary     string             `json:"summary,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Delete      *Operation         `json:"delete,omitempty"`
+	Head        *Operation         `json:"head,omitempty"`
+	Get         *Operation         `json:"get,omitempty"`
+	Options     *Operation         `json:"options,omitempty"`
+	Post        *Operation         `json:"post,omitempty"`
+	Put         *Operation         `json:"put,omitempty"`
+	Patch       *Operation         `json:"patch,omitempty"`
+	Trace       *Operation         `json:"trace,omitempty"`
+	Parameters  []OpenAPIParameter `json:"parameters,omitempty"`
+}
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	Tags        []string                    `json:"tags,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	OperationID string                      `json:"operationId,omitempty"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody                `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses,omitempty"`
+	Security    *SecurityRequirement        `json:"security,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+}
+
+// OpenAPIParameter represents a parameter from the openapi definition.
+type OpenAPIParameter struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	In          string  `json:"in,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Deprecated  bool    `json:"deprecated,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+
+	Example interface{} `json:"example,omitempty"`
+}
+
+// RequestBody describes a single request body.
+type RequestBody struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType provides schema and examples for the media type identified by its key.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// OpenAPIResponse describes a single response from an API Operation.
+type OpenAPIResponse struct {
+	Ref         string                    `json:"$ref,omitempty"`
+	Description string                    `json:"description"`
+	Headers     map[string]*OpenAPIHeader `json:"headers,omitempty"`
+	Content     map[string]*MediaType     `json:"content,omitempty"`
+}
+
+// OpenAPIHeader represents a response header from the openapi definition.
+type OpenAPIHeader struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// OpenAPISecurityScheme represents a security scheme from the openapi definition.
+type OpenAPISecurityScheme struct {
+	Type             string `json:"type"`
+	Description      string `json:"description,omitempty"`
+	Name             string `json:"name,omitempty"`
+	In               string `json:"in,omitempty"`
+	Scheme           string `json:"scheme,omitempty"`
+	BearerFormat     string `json:"bearerFormat,omitempty"`
+	OpenIDConnectURL string `json:"openIdConnectUrl,omitempty"`
+}
+
+// ToAPI converts the openapi definition into the swagger 2.0 model used for rendering.
+// Component references are resolved and schema references are rewritten
+// from `#/components/schemas/Name` to `#/definitions/Name`,
+// the $defs of the component schemas and the inline schemas are hoisted into the definitions.
+// An error listing all of them is returned if any component or schema reference cannot be resolved.
+func (o *OpenAPI) ToAPI() (*API, error) {
+	components := o.Components
+	if components == nil {
+		components = &Components{}
+	}
+
+	api := &API{
+		Swagger:     o.OpenAPI,
+		Info:        o.Info,
+		Servers:     o.Servers,
+		Tags:        o.Tags,
+		Security:    o.Security,
+		Definitions: make(map[string]*Schema, len(components.Schemas)),
+	}
+	for name, s := range components.Schemas {
+		hoistDefinition(api.Definitions, name, s, definitionsPrefix+name+"/$defs/")
+	}
+	if len(components.SecuritySchemes) > 0 {
+		api.SecurityDefinitions = make(map[string]*SecurityScheme, len(components.SecuritySchemes))
+		for name, s := range components.SecuritySchemes {
+			if s == nil {
+				continue
+			}
+			api.SecurityDefinitions[name] = &SecurityScheme{
+				Type:             s.Type,
+				Description:      s.Description,
+				Name:             s.Name,
+				In:               s.In,
+				Scheme:           s.Scheme,
+				BearerFormat:     s.BearerFormat,
+				OpenIDConnectURL: s.OpenIDConnectURL,
+			}
+		}
+	}
+
+	c := &converter{
+		components:  components,
+		definitions: api.Definitions,
+		contents:    make(map[string]convertedContent),
+	}
+	if len(o.Paths) > 0 {
+		api.Paths = make(map[string]*Endpoints, len(o.Paths))
+		paths := make([]string, 0, len(o.Paths))
+		for p := range o.Paths {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			item := o.Paths[p]
+			if item == nil {
+				continue
+			}
+			api.Paths[p] = &Endpoints{
+				Delete:  c.convertOperation(p, "delete", item, item.Delete),
+				Head:    c.convertOperation(p, "head", item, item.Head),
+				Get:     c.convertOperation(p, "get", item, item.Get),
+				Options: c.convertOperation(p, "options", item, item.Options),
+				Post:    c.convertOperation(p, "post", item, item.Post),
+				Put:     c.convertOperation(p, "put", item, item.Put),
+				Patch:   c.convertOperation(p, "patch", item, item.Patch),
+				Trace:   c.convertOperation(p, "trace", item, item.Trace),
+			}
+		}
+	}
+	if len(api.Definitions) == 0 {
+		api.Definitions = nil
+	}
+	// the schema references are checked as well, so that all the undefined references are reported at once
+	dangling := append(c.dangling, api.danglingRefs(func(ref string) bool {
+		name := strings.TrimPrefix(ref, definitionsPrefix)
+		_, ok := api.Definitions[name]
+		return ok && name != ref
+	})...)
+	if len(dangling) > 0 {
+		return nil, fmt.Errorf("undefined $ref: %s", strings.Join(dangling, ", "))
+	}
+	return api, nil
+}
+
+// converter converts the operations of an openapi definition,
+// it collects the component references which cannot be resolved.
+type converter struct {
+	components  *Components
+	definitions map[string]*Schema
+	dangling    []string
+
+	// contents caches the converted content by json pointer,
+	// the content of a component is shared by the operations referencing it.
+	contents map[string]convertedContent
+}
+
+type convertedContent struct {
+	mediaTypes []string
+	schema     *Schema
+	contents   []MediaContent
+}
+
+func (c *converter) convertOperation(path, method string, item *PathItem, op *Operation) *Endpoint {
+	if op == nil {
+		return nil
+	}
+	e := &Endpoint{
+		Tags:        op.Tags,
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: op.OperationID,
+		Security:    op.Security,
+		Deprecated:  op.Deprecated,
+	}
+	if e.Summary == "" {
+		e.Summary = item.Summary
+	}
+	if e.Description == "" {
+		e.Description = item.Description
+	}
+
+	// Operation level parameters override path level parameters with the same name and location.
+	location := strings.ToUpper(method) + " " + path
+	opPointer := JSONPointer("paths", path, method)
+	opParams := make([]OpenAPIParameter, 0, len(op.Parameters))
+	for _, p := range op.Parameters {
+		opParams = append(opParams, c.resolveParameter(p, location))
+	}
+	for i, p := range item.Parameters {
+		p = c.resolveParameter(p, "path "+path)
+		overridden := false
+		for _, v := range opParams {
+			if v.Name == p.Name && v.In == p.In {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			param := convertParameter(p)
+			param.pointer = JSONPointer("paths", path, "parameters", strconv.Itoa(i))
+			e.Parameters = append(e.Parameters, param)
+		}
+	}
+	for i, p := range opParams {
+		param := convertParameter(p)
+		param.pointer = opPointer + JSONPointer("parameters", strconv.Itoa(i))
+		e.Parameters = append(e.Parameters, param)
+	}
+
+	if body, pointer := c.resolveRequestBody(op.RequestBody, location+" requestBody"); body != nil {
+		if pointer == "" {
+			pointer = opPointer + JSONPointer("requestBody")
+		}
+		mediaTypes, schema, contents := c.convertContent(body.Content, pointer)
+		e.Consumes = mediaTypes
+		e.Parameters = append(e.Parameters, Parameter{
+			Name:        "body",
+			In:          "body",
+			Description: body.Description,
+			Required:    body.Required,
+			Schema:      schema,
+			Contents:    contents,
+			pointer:     opPointer + JSONPointer("requestBody"),
+		})
+	}
+
+	if len(op.Responses) > 0 {
+		e.Responses = make(map[string]*Response, len(op.Responses))
+		produces := make(map[string]struct{})
+		codes := make([]string, 0, len(op.Responses))
+		for code := range op.Responses {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			res, pointer := c.resolveResponse(op.Responses[code], location+" response "+code)
+			if res == nil {
+				continue
+			}
+			if pointer == "" {
+				pointer = opPointer + JSONPointer("responses", code)
+			}
+			mediaTypes, schema, contents := c.convertContent(res.Content, pointer)
+			for _, mt := range mediaTypes {
+				produces[mt] = struct{}{}
+			}
+			e.Responses[code] = &Response{
+				Description: res.Description,
+				Schema:      schema,
+				Headers:     c.convertHeaders(res.Headers, location+" response "+code),
+				MediaTypes:  mediaTypes,
+				Contents:    contents,
+			}
+		}
+		e.Produces = sortedKeys(produces)
+	}
+	return e
+}
+
+// convertContent returns all media types of the content in order, the schema of the preferred one,
+// and the schema of each media type if the media types have different schemas.
+// JSON is preferred over other types.
+func (c *converter) convertContent(content map[string]*MediaType, pointer string) ([]string, *Schema, []MediaContent) {
+	v, ok := c.contents[pointer]
+	if !ok {
+		v.mediaTypes, v.schema, v.contents = c.newContent(content, pointer)
+		c.contents[pointer] = v
+	}
+	return v.mediaTypes, v.schema, v.contents
+}
+
+func (c *converter) newContent(content map[string]*MediaType, pointer string) ([]string, *Schema, []MediaContent) {
+	mediaTypes, preferred := selectMediaType(content)
+	if len(mediaTypes) == 0 {
+		return nil, nil, nil
+	}
+
+	schemaOf := func(mt string) *Schema {
+		if v := content[mt]; v != nil {
+			return v.Schema
+		}
+		return nil
+	}
+	differ := false
+	for _, mt := range mediaTypes[1:] {
+		if !reflect.DeepEqual(schemaOf(mt), schemaOf(mediaTypes[0])) {
+			differ = true
+			break
+		}
+	}
+	if !differ {
+		return mediaTypes, c.inlineSchema(schemaOf(preferred), pointer+JSONPointer("content", preferred, "schema")), nil
+	}
+
+	var schema *Schema
+	contents := make([]MediaContent, 0, len(mediaTypes))
+	for _, mt := range mediaTypes {
+		s := c.inlineSchema(schemaOf(mt), pointer+JSONPointer("content", mt, "schema"))
+		if mt == preferred {
+			schema = s
+		}
+		contents = append(contents, MediaContent{MediaType: mt, Schema: s})
+	}
+	return mediaTypes, schema, contents
+}
+
+// inlineSchema rewrites the references of an inline schema,
+// its $defs are hoisted into the definitions named as their json pointer,
+// e.g. `paths/~1pets/post/requestBody/content/application~1json/schema/$defs/Name`.
+func (c *converter) inlineSchema(s *Schema, pointer string) *Schema {
+	if s == nil {
+		return nil
+	}
+	name := strings.TrimPrefix(pointer, "/")
+	defs := definitionsPrefix + name + "/$defs/"
+	for defName, v := range s.Defs {
+		hoistDefinition(c.definitions, name+"/$defs/"+defName, v, defs)
+	}
+	return rewriteSchemaRefs(s, defs)
+}
+
+func (c *converter) convertHeaders(headers map[string]*OpenAPIHeader, location string) map[string]Header {
+	if len(headers) == 0 {
+		return nil
+	}
+	out := make(map[string]Header, len(headers))
+	for _, name := range sortedHeaderNames(headers) {
+		h := headers[name]
+		if h != nil && h.Ref != "" {
+			component, ok := componentName(h.Ref, "headers")
+			v := c.components.Headers[component]
+			if !ok || v == nil {
+				c.undefined(h.Ref, location+" header "+name)
+				continue
+			}
+			h = v
+		}
+		if h == nil {
+			continue
+		}
+		header := Header{Description: h.Description}
+		if h.Schema != nil {
+			header.Type = h.Schema.Type.String()
+			header.Format = h.Schema.Format
+		}
+		out[name] = header
+	}
+	return out
+}
+
+func (c *converter) resolveParameter(p OpenAPIParameter, location string) OpenAPIParameter {
+	if p.Ref == "" {
+		return p
+	}
+	if name, ok := componentName(p.Ref, "parameters"); ok && c.components.Parameters[name] != nil {
+		return *c.components.Parameters[name]
+	}
+	c.undefined(p.Ref, location)
+	return p
+}
+
+// resolveRequestBody returns the request body and the json pointer of the component it references.
+func (c *converter) resolveRequestBody(body *RequestBody, location string) (*RequestBody, string) {
+	if body == nil || body.Ref == "" {
+		return body, ""
+	}
+	name, ok := componentName(body.Ref, "requestBodies")
+	if v := c.components.RequestBodies[name]; ok && v != nil {
+		return v, JSONPointer("components", "requestBodies", name)
+	}
+	c.undefined(body.Ref, location)
+	return nil, ""
+}
+
+// resolveResponse returns the response and the json pointer of the component it references.
+func (c *converter) resolveResponse(res *OpenAPIResponse, location string) (*OpenAPIResponse, string) {
+	if res == nil || res.Ref == "" {
+		return res, ""
+	}
+	name, ok := componentName(res.Ref, "responses")
+	if v := c.components.Responses[name]; ok && v != nil {
+		return v, JSONPointer("components", "responses", name)
+	}
+	c.undefined(res.Ref, location)
+	return nil, ""
+}
+
+func (c *converter) undefined(ref, location string) {
+	c.dangling = append(c.dangling, fmt.Sprintf("%s (in %s)", ref, location))
+}
+
+func convertParameter(p OpenAPIParameter) Parameter {
+	param := Parameter{
+		Name:        p.Name,
+		In:          p.In,
+		Description: p.Description,
+		Required:    p.Required,
+		Example:     p.Example,
+	}
+	if s := p.Schema; s != nil {
+		param.Type = s.Type
+		param.Format = s.Format
+		param.Default = s.Default
+		param.Items = rewriteSchemaRefs(s.Items, "")
+		param.Minimum, param.Maximum = s.Minimum, s.Maximum
+		param.ExclusiveMinimum, param.ExclusiveMaximum = s.ExclusiveMinimum, s.ExclusiveMaximum
+		param.MultipleOf = s.MultipleOf
+		param.MinLength, param.MaxLength = s.MinLength, s.MaxLength
+		param.Pattern = s.Pattern
+		param.MinItems, param.MaxItems = s.MinItems, s.MaxItems
+		param.UniqueItems = s.UniqueItems
+		for _, v := range s.Enum {
+			param.Enum = append(param.Enum, v)
+		}
+		if param.Example == nil && s.example() != "" {
+			param.Example = s.example()
+		}
+	}
+	return param
+}
+
+// selectMediaType returns all media types of the content in order
+// and the preferred one, JSON is preferred over other types.
+func selectMediaType(content map[string]*MediaType) ([]string, string) {
+	if len(content) == 0 {
+		return nil, ""
+	}
+	mediaTypes := make([]string, 0, len(content))
+	for mt := range content {
+		mediaTypes = append(mediaTypes, mt)
+	}
+	sort.Strings(mediaTypes)
+
+	for _, mt := range mediaTypes {
+		if strings.Contains(mt, "json") {
+			return mediaTypes, mt
+		}
+	}
+	return mediaTypes, mediaTypes[0]
+}
+
+// rewriteSchemaRefs rewrites all component references of the schema to definition references in place.
+// Local `#/$defs/Name` references are rewritten relative to defs, the hoisted $defs of the root schema.
+func rewriteSchemaRefs(s *Schema, defs string) *Schema {
+	if s == nil {
+		return nil
+	}
+	switch {
+	case strings.HasPrefix(s.Ref, componentSchemasPrefix):
+		s.Ref = definitionsPrefix + strings.TrimPrefix(s.Ref, componentSchemasPrefix)
+	case defs != "" && strings.HasPrefix(s.Ref, localDefsPrefix):
+		s.Ref = defs + strings.TrimPrefix(s.Ref, localDefsPrefix)
+	}
+	for _, v := range s.subSchemas() {
+		rewriteSchemaRefs(v, defs)
+	}
+	return s
+}
+
+// hoistDefinition adds the schema and all of its nested $defs to the definitions,
+// a nested definition is named as its json pointer, e.g. `Pet/$defs/Name`.
+func hoistDefinition(definitions map[string]*Schema, name string, s *Schema, defs string) {
+	if s == nil {
+		return
+	}
+	definitions[name] = rewriteSchemaRefs(s, defs)
+	for defName, v := range s.Defs {
+		hoistDefinition(definitions, name+"/$defs/"+defName, v, defs)
+	}
+}
+
+// componentName returns the name of the component the reference points to,
+// false if it does not point to a component of the kind, e.g. `#/components/schemas/Pet` used as a parameter.
+func componentName(ref, kind string) (string, bool) {
+	name := strings.TrimPrefix(ref, "#/components/"+kind+"/")
+	if name == ref || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return pointerUnescaper.Replace(name), true
+}
+
+func sortedHeaderNames(headers map[string]*OpenAPIHeader) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,187 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import "testing"
+
+func TestOpenAPIToAPI(t *testing.T) {
+	nameDefs := map[string]*Schema{"Name": {Type: String}}
+	tests := []struct {
+		name    string
+		paths   map[string]*PathItem
+		wantErr string
+		check   func(t *testing.T, api *API)
+	}{
+		{
+			name: "dangling refs",
+			paths: map[string]*PathItem{"/pets": {Get: &Operation{
+				Parameters:  []OpenAPIParameter{{Ref: "#/components/parameters/Missing"}},
+				RequestBody: &RequestBody{Ref: "#/components/requestBodies/Missing"},
+				Responses: map[string]*OpenAPIResponse{
+					"200": {Ref: "#/components/responses/Missing"},
+					"201": {Headers: map[string]*OpenAPIHeader{"X-Rate": {Ref: "#/components/headers/Missing"}}},
+				},
+			}}},
+			wantErr: "undefined $ref: #/components/parameters/Missing (in GET /pets), " +
+				"#/components/requestBodies/Missing (in GET /pets requestBody), " +
+				"#/components/responses/Missing (in GET /pets response 200), " +
+				"#/components/headers/Missing (in GET /pets response 201 header X-Rate)",
+		},
+		{
+			name: "refs to other kinds of components",
+			paths: map[string]*PathItem{"/pets": {Get: &Operation{
+				Parameters: []OpenAPIParameter{{Ref: "#/components/responses/Limit"}, {Ref: "#/components/parameters/Limit/schema"}},
+				Responses: map[string]*OpenAPIResponse{
+					"200": {Ref: "#/components/schemas/Pet"},
+				},
+			}}},
+			wantErr: "undefined $ref: #/components/responses/Limit (in GET /pets), " +
+				"#/components/parameters/Limit/schema (in GET /pets), " +
+				"#/components/schemas/Pet (in GET /pets response 200)",
+		},
+		{
+			name: "dangling component and schema refs",
+			paths: map[string]*PathItem{"/pets": {Get: &Operation{
+				Parameters: []OpenAPIParameter{{Ref: "#/components/parameters/Missing"}},
+				Responses: map[string]*OpenAPIResponse{"200": {Content: map[string]*MediaType{
+					"application/json": {Schema: &Schema{Type: Array, Items: &Schema{Ref: "#/components/schemas/Missing"}}},
+				}}},
+			}}},
+			wantErr: "undefined $ref: #/components/parameters/Missing (in GET /pets), " +
+				"#/definitions/Missing (in GET /pets response 200)",
+		},
+		{
+			name: "escaped component names",
+			paths: map[string]*PathItem{"/pets": {Get: &Operation{
+				Parameters: []OpenAPIParameter{{Ref: "#/components/parameters/page~1size"}},
+			}}},
+			check: func(t *testing.T, api *API) {
+				if params := api.Paths["/pets"].Get.Parameters; len(params) != 1 || params[0].Name != "size" {
+					t.Errorf("parameters = %+v, want the page/size parameter", params)
+				}
+			},
+		},
+		{
+			name: "array parameter",
+			paths: map[string]*PathItem{"/pets": {Get: &Operation{
+				Parameters: []OpenAPIParameter{{Name: "tags", In: "query", Schema: &Schema{
+					Type:     Array,
+					MinItems: intPtr(1),
+					MaxItems: intPtr(5),
+					Items:    &Schema{Ref: "#/components/schemas/Pet"},
+				}}},
+			}}},
+			check: func(t *testing.T, api *API) {
+				p := api.Paths["/pets"].Get.Parameters[0]
+				if p.Type != Array || p.Items == nil || p.Items.Ref != "#/definitions/Pet" {
+					t.Fatalf("parameter = %+v, want an array of the Pet definition", p)
+				}
+				if p.MinItems == nil || *p.MinItems != 1 || p.MaxItems == nil || *p.MaxItems != 5 {
+					t.Errorf("items bounds = %v..%v, want 1..5", p.MinItems, p.MaxItems)
+				}
+			},
+		},
+		{
+			name: "dangling parameter items",
+			paths: map[string]*PathItem{"/pets": {Get: &Operation{
+				Parameters: []OpenAPIParameter{{Name: "tags", In: "query", Schema: &Schema{
+					Type:  Array,
+					Items: &Schema{Ref: "#/components/schemas/Missing"},
+				}}},
+			}}},
+			wantErr: "undefined $ref: #/definitions/Missing (in GET /pets parameter tags items)",
+		},
+		{
+			name: "content per media type",
+			paths: map[string]*PathItem{"/pets": {Post: &Operation{
+				RequestBody: &RequestBody{Content: map[string]*MediaType{
+					"application/json": {Schema: &Schema{Ref: "#/components/schemas/Pet"}},
+					"text/plain":       {Schema: &Schema{Type: String}},
+				}},
+				Responses: map[string]*OpenAPIResponse{"200": {Content: map[string]*MediaType{
+					"application/json": {Schema: &Schema{Ref: "#/components/schemas/Pet"}},
+					"application/xml":  {Schema: &Schema{Ref: "#/components/schemas/Pet"}},
+				}}},
+			}}},
+			check: func(t *testing.T, api *API) {
+				e := api.Paths["/pets"].Post
+				body := e.Parameters[0]
+				if body.Schema.Ref != "#/definitions/Pet" || len(body.Contents) != 2 {
+					t.Fatalf("body schema = %q with %d contents, want the json schema and 2 contents", body.Schema.Ref, len(body.Contents))
+				}
+				if c := body.Contents[1]; c.MediaType != "text/plain" || c.Schema.Type != String {
+					t.Errorf("body content = %+v, want the text/plain string schema", c)
+				}
+				if res := e.Responses["200"]; res.Contents != nil {
+					t.Errorf("response contents = %+v, want none for the same schemas", res.Contents)
+				}
+			},
+		},
+		{
+			name: "inline $defs",
+			paths: map[string]*PathItem{"/pets": {Post: &Operation{
+				RequestBody: &RequestBody{Content: map[string]*MediaType{
+					"application/json": {Schema: &Schema{
+						Type:       Object,
+						Properties: map[string]*Schema{"name": {Ref: "#/$defs/Name"}},
+						Defs:       nameDefs,
+					}},
+				}},
+			}}},
+			check: func(t *testing.T, api *API) {
+				name := "paths/~1pets/post/requestBody/content/application~1json/schema/$defs/Name"
+				if _, ok := api.Definitions[name]; !ok {
+					t.Fatalf("definitions = %v, want %s", api.Definitions, name)
+				}
+				ref := api.Paths["/pets"].Post.Parameters[0].Schema.Properties["name"].Ref
+				if ref != DefinitionRef(name) {
+					t.Errorf("ref = %q, want %q", ref, DefinitionRef(name))
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &OpenAPI{
+				OpenAPI: "3.1.0",
+				Paths:   tt.paths,
+				Components: &Components{
+					Schemas: map[string]*Schema{"Pet": {Type: Object}},
+					Parameters: map[string]*OpenAPIParameter{
+						"Limit":     {Name: "limit", In: "query", Schema: &Schema{Type: Integer}},
+						"page/size": {Name: "size", In: "query", Schema: &Schema{Type: Integer}},
+					},
+					SecuritySchemes: map[string]*OpenAPISecurityScheme{
+						"jwt": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+					},
+				},
+			}
+			api, err := spec.ToAPI()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := api.SecurityDefinitions["jwt"]; s == nil || s.Scheme != "bearer" || s.BearerFormat != "JWT" {
+				t.Errorf("security scheme = %+v, want the bearer scheme and format", s)
+			}
+			tt.check(t, api)
+		})
+	}
+}
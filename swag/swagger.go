@@ -17,6 +17,11 @@ package swag
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
@@ -28,6 +33,9 @@ type API struct {
 	set    map[string]interface{}
 	rowSet map[string][]Row
 
+	// examples generates the example values missing in the schemas, the default generator is used if nil.
+	examples *ExampleGenerator
+
 	// Required. Specifies the Swagger Specification version being used.
 	// It can be used by the Swagger UI and other clients to interpret the API listing.
 	// The value MUST be "2.0".
@@ -55,12 +63,24 @@ type API struct {
 	// If the schemes is not included, the default scheme to be used is the one used to access the Swagger definition itself.
 	Schemes []string `json:"schemes,omitempty"`
 
+	// The servers converted from an OpenAPI 3.x definition.
+	// It takes precedence over Schemes, Host and BasePath when rendering.
+	Servers []Server `json:"-"`
+
 	// Required. The available paths and operations for the API.
 	Paths map[string]*Endpoints `json:"paths,omitempty"`
 
 	// An object to hold data types produced and consumed by operations.
 	Definitions map[string]*Schema `json:"definitions,omitempty"`
 
+	// An object to hold parameters that can be used across operations.
+	// This property does not define global parameters for all operations.
+	Parameters map[string]*Parameter `json:"parameters,omitempty"`
+
+	// An object to hold responses that can be used across operations.
+	// This property does not define global responses for all operations.
+	Responses map[string]*Response `json:"responses,omitempty"`
+
 	// A list of tags used by the specification with additional metadata.
 	// The order of the tags can be used to reflect on their order by the parsing tools.
 	// Not all tags that are used by the Operation Object must be declared.
@@ -78,21 +98,185 @@ type API struct {
 	Security *SecurityRequirement `json:"security,omitempty"`
 }
 
-func (a *API) TransformSchemas() {
-	a.set = ConvertSchemaToMap(a.Definitions)
-	a.rowSet = ConvertSchemaToRowSet(a.Definitions)
+// TransformSchemas resolves the shared parameters and responses of the operations
+// and converts the definitions for rendering,
+// an error is returned if any reference to an undefined definition, parameter or response is found.
+func (a *API) TransformSchemas() error {
+	if err := a.resolveReferences(); err != nil {
+		return err
+	}
+	set, err := a.exampleGenerator().ConvertSchemaToMap(a.Definitions)
+	if err != nil {
+		return err
+	}
+	rowSet, err := ConvertSchemaToRowSet(a.Definitions)
+	if err != nil {
+		return err
+	}
+	a.set, a.rowSet = set, rowSet
+	return a.checkRefs()
+}
+
+// resolveReferences replaces the references to the top-level parameters and responses with their values,
+// and adds the path level parameters to each operation of the path,
+// unless the operation overrides the parameter with the same name and location.
+func (a *API) resolveReferences() error {
+	var dangling []string
+	resolveParameter := func(p Parameter, location string) Parameter {
+		if p.Ref == "" {
+			return p
+		}
+		name := strings.TrimPrefix(p.Ref, parametersPrefix)
+		if v, ok := a.Parameters[name]; ok && v != nil && name != p.Ref {
+			return *v
+		}
+		dangling = append(dangling, fmt.Sprintf("%s (in %s)", p.Ref, location))
+		return p
+	}
+
+	for _, path := range sortedPaths(a) {
+		es := a.Paths[path]
+		if es == nil {
+			continue
+		}
+		for i, p := range es.Parameters {
+			param := resolveParameter(p, "path "+path)
+			if param.pointer = p.pointer; param.pointer == "" {
+				param.pointer = JSONPointer("paths", path, "parameters", strconv.Itoa(i))
+			}
+			es.Parameters[i] = param
+		}
+		for _, e := range es.All() {
+			location := e.Method + " " + path
+			params := make([]Parameter, 0, len(es.Parameters)+len(e.Parameters))
+			for i, p := range e.Parameters {
+				param := resolveParameter(p, location)
+				if param.pointer = p.pointer; param.pointer == "" {
+					param.pointer = JSONPointer("paths", path, strings.ToLower(e.Method), "parameters", strconv.Itoa(i))
+				}
+				params = append(params, param)
+			}
+			// the path level parameters go first as in the OpenAPI 3.x conversion
+			var merged []Parameter
+			for _, p := range es.Parameters {
+				if p.Ref != "" || hasParameter(params, p) {
+					continue
+				}
+				merged = append(merged, p)
+			}
+			e.Parameters = append(merged, params...)
+
+			for _, code := range sortedResponseCodes(e.Responses) {
+				res := e.Responses[code]
+				if res == nil || res.Ref == "" {
+					continue
+				}
+				name := strings.TrimPrefix(res.Ref, responsesPrefix)
+				v, ok := a.Responses[name]
+				if !ok || v == nil || name == res.Ref {
+					dangling = append(dangling, fmt.Sprintf("%s (in %s response %s)", res.Ref, location, code))
+					continue
+				}
+				shared := *v
+				e.Responses[code] = &shared
+			}
+		}
+	}
+	if len(dangling) > 0 {
+		return fmt.Errorf("undefined $ref: %s", strings.Join(dangling, ", "))
+	}
+	return nil
+}
+
+func hasParameter(params []Parameter, p Parameter) bool {
+	for _, v := range params {
+		if v.Name == p.Name && v.In == p.In {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRefs checks that all the references used by the operations are defined.
+func (a *API) checkRefs() error {
+	dangling := a.danglingRefs(func(ref string) bool {
+		_, ok := a.set[ref]
+		return ok
+	})
+	if len(dangling) > 0 {
+		return fmt.Errorf("undefined $ref: %s", strings.Join(dangling, ", "))
+	}
+	return nil
+}
+
+// danglingRefs returns the schema references used by the operations which are not defined,
+// each followed by where it is used.
+func (a *API) danglingRefs(defined func(ref string) bool) []string {
+	var dangling []string
+	check := func(s *Schema, location string) {
+		if s == nil {
+			return
+		}
+		for _, ref := range s.Refs() {
+			if !defined(ref) {
+				dangling = append(dangling, fmt.Sprintf("%s (in %s)", ref, location))
+			}
+		}
+	}
+	for _, e := range a.AllEndpoints() {
+		for _, p := range e.Parameters {
+			location := fmt.Sprintf("%s %s parameter %s", e.Method, e.Path, p.Name)
+			check(p.Schema, location)
+			check(p.Items, location+" items")
+			for _, c := range p.Contents {
+				check(c.Schema, location+" "+c.MediaType)
+			}
+		}
+		for _, code := range sortedResponseCodes(e.Responses) {
+			if res := e.Responses[code]; res != nil {
+				location := fmt.Sprintf("%s %s response %s", e.Method, e.Path, code)
+				check(res.Schema, location)
+				for _, c := range res.Contents {
+					check(c.Schema, location+" "+c.MediaType)
+				}
+			}
+		}
+	}
+	return dangling
+}
+
+func sortedResponseCodes(responses map[string]*Response) []string {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
 }
 
 func (a *API) GetObject(schema *Schema) interface{} {
 	if schema == nil {
 		return nil
 	}
-	if obj, ok := ConvertSchemaToValue(a.set, schema); ok {
+	if obj, ok := a.exampleGenerator().ConvertSchemaToValue(a.set, schema); ok {
 		return obj
 	}
 	return nil
 }
 
+// SetExampleGenerator replaces the generator of the example values and converts the definitions again.
+func (a *API) SetExampleGenerator(g *ExampleGenerator) error {
+	a.examples = g
+	return a.TransformSchemas()
+}
+
+func (a *API) exampleGenerator() *ExampleGenerator {
+	if a.examples == nil {
+		return defaultExampleGenerator
+	}
+	return a.examples
+}
+
 func (a *API) GetRows(schema *Schema) []Row {
 	if schema == nil {
 		return nil
@@ -110,6 +294,164 @@ func (a *API) GetRows(schema *Schema) []Row {
 	return nil
 }
 
+// Variant is an alternative of a oneOf or anyOf schema.
+type Variant struct {
+	Label  string
+	Schema *Schema
+}
+
+// GetVariants returns the alternatives if the schema is a oneOf or anyOf schema,
+// each of them is combined with the rest of the schema.
+func (a *API) GetVariants(schema *Schema) []Variant {
+	s := a.resolveSchema(schema)
+	if s == nil {
+		return nil
+	}
+	base := *s
+	base.OneOf, base.AnyOf = nil, nil
+
+	var variants []Variant
+	for i, v := range s.OneOf {
+		variants = append(variants, Variant{
+			Label:  variantLabel("oneOf", i, v),
+			Schema: &Schema{AllOf: []*Schema{&base, v}},
+		})
+	}
+	for i, v := range s.AnyOf {
+		variants = append(variants, Variant{
+			Label:  variantLabel("anyOf", i, v),
+			Schema: &Schema{AllOf: []*Schema{&base, v}},
+		})
+	}
+	return variants
+}
+
+// DefinitionName returns the name of the definition referenced by the ref,
+// false is returned if the ref does not point to a definition.
+func DefinitionName(ref string) (string, bool) {
+	if !strings.HasPrefix(ref, definitionsPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(ref, definitionsPrefix), true
+}
+
+// DefinitionRef returns the ref pointing to the definition with the name.
+func DefinitionRef(name string) string {
+	return definitionsPrefix + name
+}
+
+// resolveSchema follows the references of the schema to the definition.
+func (a *API) resolveSchema(schema *Schema) *Schema {
+	seen := make(map[string]struct{})
+	for schema != nil && schema.Ref != "" {
+		if _, ok := seen[schema.Ref]; ok {
+			return nil
+		}
+		seen[schema.Ref] = struct{}{}
+		schema = a.Definitions[strings.TrimPrefix(schema.Ref, definitionsPrefix)]
+	}
+	return schema
+}
+
+// DefaultTag is the name of the tag group for operations without tags.
+const DefaultTag = "default"
+
+// TagGroup represents the endpoints grouped by a tag.
+type TagGroup struct {
+	Tag Tag
+
+	// Declared reports whether the tag is declared in the top-level tags.
+	Declared bool
+
+	Endpoints []*Endpoint
+}
+
+// TagGroups returns the endpoints grouped by their tags.
+// The declared tags come first in order, followed by the tags used by operations
+// but not declared, and the operations without tags are grouped under DefaultTag at last.
+// An endpoint with multiple tags appears in each of the groups.
+func (a *API) TagGroups() []TagGroup {
+	groups := make([]TagGroup, 0, len(a.Tags))
+	index := make(map[string]int, len(a.Tags))
+	for _, tag := range a.Tags {
+		if _, ok := index[tag.Name]; ok {
+			continue
+		}
+		index[tag.Name] = len(groups)
+		groups = append(groups, TagGroup{Tag: tag, Declared: true})
+	}
+
+	var untagged []*Endpoint
+	for _, e := range a.AllEndpoints() {
+		if len(e.Tags) == 0 {
+			untagged = append(untagged, e)
+			continue
+		}
+		for _, name := range e.Tags {
+			i, ok := index[name]
+			if !ok {
+				i = len(groups)
+				index[name] = i
+				groups = append(groups, TagGroup{Tag: Tag{Name: name}})
+			}
+			groups[i].Endpoints = append(groups[i].Endpoints, e)
+		}
+	}
+	if len(untagged) > 0 {
+		if i, ok := index[DefaultTag]; ok {
+			groups[i].Endpoints = append(groups[i].Endpoints, untagged...)
+		} else {
+			groups = append(groups, TagGroup{Tag: Tag{Name: DefaultTag}, Endpoints: untagged})
+		}
+	}
+	return groups
+}
+
+// AllEndpoints returns all the endpoints ordered by path and method,
+// with the Path and Method of each endpoint set.
+func (a *API) AllEndpoints() []*Endpoint {
+	paths := make([]string, 0, len(a.Paths))
+	for p := range a.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var set []*Endpoint
+	for _, p := range paths {
+		es := a.Paths[p]
+		if es == nil {
+			continue
+		}
+		for _, e := range es.All() {
+			e.Path = p
+			set = append(set, e)
+		}
+	}
+	return set
+}
+
+// Warnings returns the problems of the definition which affect the rendered document.
+func (a *API) Warnings() []string {
+	declared := make(map[string]struct{}, len(a.Tags))
+	for _, tag := range a.Tags {
+		declared[tag.Name] = struct{}{}
+	}
+
+	var warnings []string
+	for _, e := range a.AllEndpoints() {
+		if len(e.Tags) == 0 {
+			warnings = append(warnings, fmt.Sprintf("operation %s %s has no tags, grouped under %q", e.Method, e.Path, DefaultTag))
+			continue
+		}
+		for _, name := range e.Tags {
+			if _, ok := declared[name]; !ok {
+				warnings = append(warnings, fmt.Sprintf("operation %s %s uses undeclared tag %q", e.Method, e.Path, name))
+			}
+		}
+	}
+	return warnings
+}
+
 // Info provides metadata about the API.
 // The metadata can be used by the clients if needed, and can be presented in the Swagger-UI for convenience.
 type Info struct {
@@ -152,6 +494,10 @@ type License struct {
 
 // Endpoints represents all the swagger endpoints associated with a particular path
 type Endpoints struct {
+	// The parameters shared by all the endpoints of the path,
+	// they are added to the endpoints by TransformSchemas.
+	Parameters []Parameter `json:"parameters,omitempty"`
+
 	Delete  *Endpoint `json:"delete,omitempty"`
 	Head    *Endpoint `json:"head,omitempty"`
 	Get     *Endpoint `json:"get,omitempty"`
@@ -163,6 +509,48 @@ type Endpoints struct {
 	Connect *Endpoint `json:"connect,omitempty"`
 }
 
+// All returns all the endpoints with the Method of each endpoint set.
+func (es *Endpoints) All() []*Endpoint {
+	set := make([]*Endpoint, 0, 9)
+	if es.Get != nil {
+		es.Get.Method = http.MethodGet
+		set = append(set, es.Get)
+	}
+	if es.Post != nil {
+		es.Post.Method = http.MethodPost
+		set = append(set, es.Post)
+	}
+	if es.Delete != nil {
+		es.Delete.Method = http.MethodDelete
+		set = append(set, es.Delete)
+	}
+	if es.Put != nil {
+		es.Put.Method = http.MethodPut
+		set = append(set, es.Put)
+	}
+	if es.Patch != nil {
+		es.Patch.Method = http.MethodPatch
+		set = append(set, es.Patch)
+	}
+	if es.Options != nil {
+		es.Options.Method = http.MethodOptions
+		set = append(set, es.Options)
+	}
+	if es.Head != nil {
+		es.Head.Method = http.MethodHead
+		set = append(set, es.Head)
+	}
+	if es.Connect != nil {
+		es.Connect.Method = http.MethodConnect
+		set = append(set, es.Connect)
+	}
+	if es.Trace != nil {
+		es.Trace.Method = http.MethodTrace
+		set = append(set, es.Trace)
+	}
+	return set
+}
+
 // Endpoint represents an endpoint from the swagger doc
 type Endpoint struct {
 	Tags        []string             `json:"tags,omitempty"`
@@ -184,16 +572,59 @@ type Endpoint struct {
 
 // Parameter represents a parameter from the swagger doc
 type Parameter struct {
+	// The reference to a top-level parameter, e.g. `#/parameters/limit`, resolved by TransformSchemas.
+	Ref string `json:"$ref,omitempty"`
+
 	Name        string `json:"name,omitempty"`
 	In          string `json:"in,omitempty"`
 	Description string `json:"description,omitempty"`
 	Required    bool   `json:"required"`
 
-	Type    ParameterType `json:"type,omitempty"`
-	Format  string        `json:"format,omitempty"`
-	Default string        `json:"default,omitempty"`
+	Type   ParameterType `json:"type,omitempty"`
+	Format string        `json:"format,omitempty"`
+
+	// The default value of the parameter, which may be any JSON value.
+	Default interface{} `json:"default,omitempty"`
+
+	// The allowed values of the parameter, which may be any JSON values.
+	Enum []interface{} `json:"enum,omitempty"`
+
+	// The example of a non-body parameter, from the `x-example` extension
+	// or the example of an OpenAPI 3.x parameter.
+	Example interface{} `json:"x-example,omitempty"`
 
 	Schema *Schema `json:"schema,omitempty"`
+
+	// The items of an array parameter other than the body,
+	// converted from the schema of an OpenAPI 3.x parameter.
+	Items *Schema `json:"items,omitempty"`
+
+	// The validation keywords of a parameter other than the body,
+	// converted from the schema of an OpenAPI 3.x parameter.
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum bool     `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum bool     `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       *float64 `json:"multipleOf,omitempty"`
+	MinLength        *int     `json:"minLength,omitempty"`
+	MaxLength        *int     `json:"maxLength,omitempty"`
+	Pattern          string   `json:"pattern,omitempty"`
+	MinItems         *int     `json:"minItems,omitempty"`
+	MaxItems         *int     `json:"maxItems,omitempty"`
+	UniqueItems      bool     `json:"uniqueItems,omitempty"`
+
+	// The schema of each media type of a body converted from an OpenAPI 3.x definition,
+	// set when the media types have different schemas, Schema is the preferred one.
+	Contents []MediaContent `json:"-"`
+
+	// the JSON pointer of the parameter in the source document,
+	// which is kept after the path level parameters are merged into the operations.
+	pointer string
+}
+
+// DefaultValue formats the default value as it is displayed in the document.
+func (p Parameter) DefaultValue() string {
+	return valueToString(p.Default)
 }
 
 // Schema represents a schema from the swagger doc
@@ -204,17 +635,183 @@ type Schema struct {
 	Properties map[string]*Schema `json:"properties,omitempty"`
 	Items      *Schema            `json:"items,omitempty"`
 
+	// AdditionalProperties is the schema of the values of a map, keyed by any string.
+	// The boolean `true` decodes to an empty schema and `false` disallows any other property.
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+
+	// All the non-null types of the schema, Type is the first of them.
+	// OpenAPI 3.1 allows the type to be an array, e.g. `type: [string, "null"]`.
+	Types []ParameterType `json:"-"`
+	// Nullable reports whether null is allowed, from `nullable: true` or a "null" type.
+	Nullable bool `json:"nullable,omitempty"`
+
+	// JSON Schema 2020-12 keywords used by OpenAPI 3.1.
+	Const       interface{}        `json:"const,omitempty"`
+	PrefixItems []*Schema          `json:"prefixItems,omitempty"`
+	Defs        map[string]*Schema `json:"$defs,omitempty"`
+	Examples    []interface{}      `json:"examples,omitempty"`
+
+	// Composition keywords, allOf is merged and oneOf/anyOf are rendered as variants.
+	AllOf []*Schema `json:"allOf,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	Not   *Schema   `json:"not,omitempty"`
+
+	Title       string   `json:"title,omitempty"`
 	Description string   `json:"description,omitempty"`
 	Enum        []string `json:"enum,omitempty"`
 	Format      string   `json:"format,omitempty"`
 	Example     string   `json:"example,omitempty"`
+
+	// The default value and the validation keywords, which shape the generated examples.
+	// The numeric exclusiveMinimum/exclusiveMaximum of OpenAPI 3.1 are converted to the bounds.
+	Default          interface{} `json:"default,omitempty"`
+	Minimum          *float64    `json:"minimum,omitempty"`
+	Maximum          *float64    `json:"maximum,omitempty"`
+	ExclusiveMinimum bool        `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum bool        `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       *float64    `json:"multipleOf,omitempty"`
+	MinLength        *int        `json:"minLength,omitempty"`
+	MaxLength        *int        `json:"maxLength,omitempty"`
+	Pattern          string      `json:"pattern,omitempty"`
+	MinItems         *int        `json:"minItems,omitempty"`
+	MaxItems         *int        `json:"maxItems,omitempty"`
+	UniqueItems      bool        `json:"uniqueItems,omitempty"`
+	ReadOnly         bool        `json:"readOnly,omitempty"`
+
+	// isFalse marks the boolean schema `false`, which matches nothing.
+	isFalse bool
+}
+
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	// JSON Schema allows booleans as schemas, `true` matches everything.
+	switch string(bytes.TrimSpace(b)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{isFalse: true}
+		return nil
+	}
+
+	type schema Schema
+	aux := struct {
+		*schema
+		Type             json.RawMessage `json:"type,omitempty"`
+		Enum             []interface{}   `json:"enum,omitempty"`
+		Example          json.RawMessage `json:"example,omitempty"`
+		ExclusiveMinimum json.RawMessage `json:"exclusiveMinimum,omitempty"`
+		ExclusiveMaximum json.RawMessage `json:"exclusiveMaximum,omitempty"`
+	}{schema: (*schema)(s)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if s.ExclusiveMinimum, err = exclusiveBound(aux.ExclusiveMinimum, &s.Minimum); err != nil {
+		return err
+	}
+	if s.ExclusiveMaximum, err = exclusiveBound(aux.ExclusiveMaximum, &s.Maximum); err != nil {
+		return err
+	}
+
+	if len(aux.Type) > 0 {
+		var types []ParameterType
+		if aux.Type[0] == '[' {
+			if err := json.Unmarshal(aux.Type, &types); err != nil {
+				return err
+			}
+		} else {
+			var t ParameterType
+			if err := json.Unmarshal(aux.Type, &t); err != nil {
+				return err
+			}
+			types = []ParameterType{t}
+		}
+		for _, t := range types {
+			if t == Null {
+				s.Nullable = true
+				continue
+			}
+			s.Types = append(s.Types, t)
+		}
+		if len(s.Types) > 0 {
+			s.Type = s.Types[0]
+		}
+	}
+	for _, v := range aux.Enum {
+		s.Enum = append(s.Enum, valueToString(v))
+	}
+	if len(aux.Example) > 0 {
+		var v interface{}
+		if err := json.Unmarshal(aux.Example, &v); err != nil {
+			return err
+		}
+		s.Example = valueToString(v)
+	}
+	return nil
+}
+
+// exclusiveBound decodes the exclusiveMinimum or exclusiveMaximum keyword,
+// which is a boolean modifying the bound in Swagger 2.0 and the bound itself in OpenAPI 3.1.
+func exclusiveBound(raw json.RawMessage, bound **float64) (bool, error) {
+	if len(raw) == 0 {
+		return false, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return false, err
+	}
+	switch val := v.(type) {
+	case bool:
+		return val, nil
+	case float64:
+		*bound = &val
+		return true, nil
+	}
+	return false, nil
+}
+
+// valueToString formats the decoded json value as it is displayed in the document.
+func valueToString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
 }
 
 // Response represents a response from the swagger doc
 type Response struct {
+	// The reference to a top-level response, e.g. `#/responses/NotFound`, resolved by TransformSchemas.
+	Ref string `json:"$ref,omitempty"`
+
 	Description string            `json:"description"`
 	Schema      *Schema           `json:"schema,omitempty"`
 	Headers     map[string]Header `json:"headers,omitempty"`
+
+	// The media types of the response content converted from an OpenAPI 3.x definition.
+	MediaTypes []string `json:"-"`
+
+	// The schema of each media type converted from an OpenAPI 3.x definition,
+	// set when the media types have different schemas, Schema is the preferred one.
+	Contents []MediaContent `json:"-"`
+}
+
+// MediaContent is the schema of a media type.
+type MediaContent struct {
+	MediaType string
+	Schema    *Schema
 }
 
 // Header represents a response header
@@ -239,11 +836,17 @@ type TagDocs struct {
 
 // SecurityScheme represents a security scheme from the swagger definition.
 type SecurityScheme struct {
-	Type             string            `json:"type"`
-	Description      string            `json:"description,omitempty"`
-	Name             string            `json:"name,omitempty"`
-	In               string            `json:"in,omitempty"`
-	Flow             string            `json:"flow,omitempty"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Name        string `json:"name,omitempty"`
+	In          string `json:"in,omitempty"`
+	Flow        string `json:"flow,omitempty"`
+
+	// The http authorization scheme, bearer format and OpenID Connect url of an OpenAPI 3.x security scheme.
+	Scheme           string `json:"scheme,omitempty"`
+	BearerFormat     string `json:"bearerFormat,omitempty"`
+	OpenIDConnectURL string `json:"openIdConnectUrl,omitempty"`
+
 	AuthorizationURL string            `json:"authorizationUrl,omitempty"`
 	TokenURL         string            `json:"tokenUrl,omitempty"`
 	Scopes           map[string]string `json:"scopes,omitempty"`
@@ -0,0 +1,199 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveReferences(t *testing.T) {
+	api := &API{
+		Paths: map[string]*Endpoints{
+			"/pets/{id}": {
+				Parameters: []Parameter{
+					{Ref: "#/parameters/id"},
+					{Name: "verbose", In: "query", Type: Boolean, Description: "path level"},
+				},
+				Get: &Endpoint{
+					Parameters: []Parameter{{Ref: "#/parameters/fields"}},
+					Responses: map[string]*Response{
+						"200":     {Description: "the pet"},
+						"404":     {Ref: "#/responses/NotFound"},
+						"default": {Ref: "#/responses/NotFound"},
+					},
+				},
+				Delete: &Endpoint{
+					// overrides the path level parameter with the same name and location
+					Parameters: []Parameter{
+						{Name: "verbose", In: "query", Type: String, Description: "operation level"},
+						{Name: "verbose", In: "header", Type: String},
+					},
+				},
+			},
+		},
+		Parameters: map[string]*Parameter{
+			"id":     {Name: "id", In: "path", Type: String, Required: true},
+			"fields": {Name: "fields", In: "query", Type: Array, Items: &Schema{Type: String}},
+		},
+		Responses: map[string]*Response{
+			"NotFound": {Description: "not found"},
+		},
+	}
+	if err := api.resolveReferences(); err != nil {
+		t.Fatal(err)
+	}
+
+	type param struct{ Name, In, Description string }
+	params := func(e *Endpoint) []param {
+		var out []param
+		for _, p := range e.Parameters {
+			if p.Ref != "" {
+				t.Errorf("%s %s parameter %s is not resolved", e.Method, e.Path, p.Ref)
+			}
+			out = append(out, param{Name: p.Name, In: p.In, Description: p.Description})
+		}
+		return out
+	}
+	es := api.Paths["/pets/{id}"]
+	for _, c := range []struct {
+		e    *Endpoint
+		want []param
+	}{
+		{
+			// the path level parameters go first
+			e:    es.Get,
+			want: []param{{Name: "id", In: "path"}, {Name: "verbose", In: "query", Description: "path level"}, {Name: "fields", In: "query"}},
+		},
+		{
+			e:    es.Delete,
+			want: []param{{Name: "id", In: "path"}, {Name: "verbose", In: "query", Description: "operation level"}, {Name: "verbose", In: "header"}},
+		},
+	} {
+		if got := params(c.e); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s parameters = %+v, want %+v", c.e.Method, got, c.want)
+		}
+	}
+	if es.Get.Parameters[2].Items == nil || es.Get.Parameters[2].Items.Type != String {
+		t.Errorf("the items of the referred parameter are not resolved")
+	}
+
+	for _, code := range []string{"404", "default"} {
+		res := es.Get.Responses[code]
+		if res.Ref != "" || res.Description != "not found" {
+			t.Errorf("response %s = %+v, want the NotFound response", code, res)
+		}
+	}
+	// each operation gets its own copy of the shared response
+	if es.Get.Responses["404"] == api.Responses["NotFound"] || es.Get.Responses["404"] == es.Get.Responses["default"] {
+		t.Errorf("the shared response is not copied")
+	}
+}
+
+func TestResolveReferencesDangling(t *testing.T) {
+	api := &API{
+		Paths: map[string]*Endpoints{
+			"/pets": {
+				Parameters: []Parameter{{Ref: "#/parameters/limit"}},
+				Get: &Endpoint{
+					Parameters: []Parameter{{Ref: "#/definitions/Pet"}},
+					Responses:  map[string]*Response{"404": {Ref: "#/responses/NotFound"}},
+				},
+			},
+		},
+	}
+	err := api.TransformSchemas()
+	want := "undefined $ref: #/parameters/limit (in path /pets), #/definitions/Pet (in GET /pets), " +
+		"#/responses/NotFound (in GET /pets response 404)"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %s", err, want)
+	}
+}
+
+// tagAPI declares the tags pets and stores, stores being unused,
+// and has an operation with several tags, one with an undeclared tag and one without tags.
+func tagAPI() *API {
+	ok := map[string]*Response{"200": {Description: "ok"}}
+	return &API{
+		Tags: []Tag{{Name: "pets", Description: "the pets"}, {Name: "stores"}, {Name: "pets"}},
+		Paths: map[string]*Endpoints{
+			"/pets": {
+				Get:  &Endpoint{Tags: []string{"pets", "admin"}, Responses: ok},
+				Post: &Endpoint{Tags: []string{"pets"}, Responses: ok},
+			},
+			"/health": {Get: &Endpoint{Responses: ok}},
+			"/users":  {Get: &Endpoint{Tags: []string{"users"}, Responses: ok}},
+		},
+	}
+}
+
+func TestTagGroups(t *testing.T) {
+	type group struct {
+		Tag       Tag
+		Declared  bool
+		Endpoints []string
+	}
+	summarize := func(groups []TagGroup) []group {
+		var out []group
+		for _, g := range groups {
+			v := group{Tag: g.Tag, Declared: g.Declared}
+			for _, e := range g.Endpoints {
+				v.Endpoints = append(v.Endpoints, e.Method+" "+e.Path)
+			}
+			out = append(out, v)
+		}
+		return out
+	}
+
+	want := []group{
+		{Tag: Tag{Name: "pets", Description: "the pets"}, Declared: true, Endpoints: []string{"GET /pets", "POST /pets"}},
+		{Tag: Tag{Name: "stores"}, Declared: true},
+		{Tag: Tag{Name: "admin"}, Endpoints: []string{"GET /pets"}},
+		{Tag: Tag{Name: "users"}, Endpoints: []string{"GET /users"}},
+		{Tag: Tag{Name: DefaultTag}, Endpoints: []string{"GET /health"}},
+	}
+	if got := summarize(tagAPI().TagGroups()); !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %+v, want %+v", got, want)
+	}
+
+	// the operations without tags join the declared default tag
+	api := tagAPI()
+	api.Tags = append(api.Tags, Tag{Name: DefaultTag, Description: "the others"})
+	api.Paths["/version"] = &Endpoints{Get: &Endpoint{Tags: []string{DefaultTag}}}
+	groups := summarize(api.TagGroups())
+	wantDefault := group{Tag: Tag{Name: DefaultTag, Description: "the others"}, Declared: true, Endpoints: []string{"GET /version", "GET /health"}}
+	if len(groups) != 5 || !reflect.DeepEqual(groups[2], wantDefault) {
+		t.Errorf("groups = %+v, want %+v as the third one", groups, wantDefault)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	want := []string{
+		`operation GET /health has no tags, grouped under "default"`,
+		`operation GET /pets uses undeclared tag "admin"`,
+		`operation GET /users uses undeclared tag "users"`,
+	}
+	if got := tagAPI().Warnings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("warnings = %q, want %q", got, want)
+	}
+
+	api := &API{
+		Tags:  []Tag{{Name: "pets"}},
+		Paths: map[string]*Endpoints{"/pets": {Get: &Endpoint{Tags: []string{"pets"}}}},
+	}
+	if got := api.Warnings(); len(got) != 0 {
+		t.Errorf("warnings = %q, want none", got)
+	}
+}
@@ -15,7 +15,9 @@
 package swag
 
 import (
+	"fmt"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -36,70 +38,106 @@ const (
 	Object ParameterType = "object"
 
 	File ParameterType = "file"
+
+	Null ParameterType = "null"
 )
 
-func ConvertSchemaToMap(schemas map[string]*Schema) map[string]interface{} {
-	// 解析为 ref => obj
-	set := make(map[string]interface{})
-	extra := make(map[string]*Schema)
-	for name, s := range schemas {
-		extra[name] = s
-	}
-	for len(extra) > 0 {
-		empty := make(map[string]*Schema)
-		for name, s := range extra {
-			ref := path.Join("#/definitions", name)
-			if s.Ref != "" {
-				empty[name] = s
-				continue
+// ConvertSchemaToMap converts the definitions to json example values keyed by ref.
+// A recursive reference is left out of the examples.
+// The missing examples are generated by the default example generator.
+func ConvertSchemaToMap(schemas map[string]*Schema) (map[string]interface{}, error) {
+	return defaultExampleGenerator.ConvertSchemaToMap(schemas)
+}
+
+// RecursiveMarker returns the text describing the field row of a recursive reference to the definition.
+func RecursiveMarker(name string) string {
+	return "see " + name
+}
+
+// resolveDefinitions calls convert for every definition after all the definitions it references.
+// Before a definition is converted, mark is called for it,
+// so that the recursive references to the definition can be resolved to a marker.
+// An error naming all the references to undefined definitions is returned.
+func resolveDefinitions(schemas map[string]*Schema, mark func(ref, name string, s *Schema), convert func(ref string, s *Schema)) error {
+	names := sortedPropertyNames(schemas)
+	lookup := make(map[string]string, len(names))
+	for _, name := range names {
+		lookup[path.Join("#/definitions", name)] = name
+	}
+
+	var dangling []string
+	done := make(map[string]bool, len(names))
+	var resolve func(ref string)
+	resolve = func(ref string) {
+		if _, ok := done[ref]; ok {
+			return
+		}
+		name := lookup[ref]
+		s := schemas[name]
+		if s == nil {
+			s = &Schema{}
+		}
+		// Recursive references stop here and use the marker,
+		// which names the target definition if this one is an alias.
+		done[ref] = false
+		target, targetName := s, name
+		for seen := map[string]bool{ref: true}; target.Ref != "" && !seen[target.Ref]; {
+			seen[target.Ref] = true
+			next, ok := schemas[lookup[target.Ref]]
+			if !ok || next == nil {
+				break
 			}
-			obj, ok := ConvertSchemaToValue(set, s)
-			if !ok {
-				empty[name] = s
+			target, targetName = next, lookup[target.Ref]
+		}
+		mark(ref, targetName, target)
+		for _, r := range s.Refs() {
+			if _, ok := lookup[r]; !ok {
+				dangling = append(dangling, fmt.Sprintf("%s (in %s)", r, ref))
 				continue
 			}
-			set[ref] = obj
+			resolve(r)
 		}
-		extra = empty
+		convert(ref, s)
+		done[ref] = true
+	}
+	for _, name := range names {
+		resolve(path.Join("#/definitions", name))
 	}
-	return set
+	if len(dangling) > 0 {
+		return fmt.Errorf("undefined $ref: %s", strings.Join(dangling, ", "))
+	}
+	return nil
 }
 
+// ConvertSchemaToValue converts the schema to a json example value,
+// the missing examples are generated by the default example generator.
 func ConvertSchemaToValue(set map[string]interface{}, schema *Schema) (interface{}, bool) {
-	if schema.Ref != "" {
-		if obj, ok := set[schema.Ref]; ok {
-			return obj, true
+	return defaultExampleGenerator.ConvertSchemaToValue(set, schema)
+}
+
+// mergeValue merges the objects into a new one, the existing fields are kept.
+// The other value is returned if the value is not an object.
+func mergeValue(value, other interface{}) interface{} {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		if value == nil {
+			return other
 		}
-		return nil, false
+		return value
 	}
-	var value interface{}
-	switch schema.Type {
-	case String:
-		value = schema.Example
-	case Integer:
-		value, _ = strconv.Atoi(schema.Example)
-	case Number:
-		value, _ = strconv.ParseFloat(schema.Example, 64)
-	case Boolean:
-		value, _ = strconv.ParseBool(schema.Example)
-	case Array:
-		obj, ok := ConvertSchemaToValue(set, schema.Items)
-		if !ok {
-			return nil, false
-		}
-		value = []interface{}{obj}
-	case Object:
-		objs := make(map[string]interface{})
-		for name, s := range schema.Properties {
-			obj, ok := ConvertSchemaToValue(set, s)
-			if !ok {
-				return nil, false
-			}
-			objs[name] = obj
-		}
-		value = objs
+	otherObj, ok := other.(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	merged := make(map[string]interface{}, len(obj)+len(otherObj))
+	for k, v := range otherObj {
+		merged[k] = v
+	}
+	for k, v := range obj {
+		merged[k] = v
 	}
-	return value, true
+	return merged
 }
 
 type Row struct {
@@ -109,32 +147,36 @@ type Row struct {
 	Description string
 	Enum        string
 	Example     string
+
+	// EnumValues are the allowed values joined by Enum.
+	EnumValues []string
+
+	// Constraints describes the validation keywords, e.g. ">= 1, <= 100, multiple of 5".
+	Constraints string
+
+	// Recursive reports whether the row stands for a recursive reference.
+	Recursive bool
+
+	// Variant is the label of the innermost oneOf/anyOf alternative of the row, e.g. "oneOf Cat".
+	Variant string
 }
 
-func ConvertSchemaToRowSet(schemas map[string]*Schema) map[string][]Row {
+// ConvertSchemaToRowSet converts the definitions to field rows keyed by ref.
+// A recursive reference is rendered as a single row described with a "see Name" marker.
+func ConvertSchemaToRowSet(schemas map[string]*Schema) (map[string][]Row, error) {
 	set := make(map[string][]Row)
-	extra := make(map[string]*Schema)
-	for name, s := range schemas {
-		extra[name] = s
-	}
-	for len(extra) > 0 {
-		empty := make(map[string]*Schema)
-		for name, s := range extra {
-			ref := path.Join("#/definitions", name)
-			if s.Ref != "" {
-				empty[name] = s
-				continue
-			}
-			out, ok := ConvertSchemaToRow(set, s, nil, false)
-			if !ok {
-				empty[name] = s
-				continue
-			}
+	err := resolveDefinitions(schemas, func(ref, name string, s *Schema) {
+		set[ref] = []Row{{
+			Type:        s.typeName(),
+			Description: RecursiveMarker(name),
+			Recursive:   true,
+		}}
+	}, func(ref string, s *Schema) {
+		if out, ok := ConvertSchemaToRow(set, s, nil, false); ok {
 			set[ref] = out
 		}
-		extra = empty
-	}
-	return set
+	})
+	return set, err
 }
 
 func ConvertSchemaToRow(set map[string][]Row, schema *Schema, names []string, required bool) ([]Row, bool) {
@@ -148,6 +190,8 @@ func ConvertSchemaToRow(set map[string][]Row, schema *Schema, names []string, re
 			nameSet := names
 			if v.Name != "" {
 				nameSet = append(names, v.Name)
+			} else {
+				v.Required = required
 			}
 			v.Name = strings.Join(nameSet, ".")
 			current = append(current, v)
@@ -155,39 +199,284 @@ func ConvertSchemaToRow(set map[string][]Row, schema *Schema, names []string, re
 		return current, true
 	}
 
+	values := schema.Enum
+	if schema.Const != nil {
+		values = []string{valueToString(schema.Const)}
+	}
+
 	var rows []Row
 	rows = append(rows, Row{
-		Type:        schema.Type,
+		Type:        schema.typeName(),
 		Name:        strings.Join(names, "."),
 		Required:    required,
 		Description: schema.Description,
-		Enum:        strings.Join(schema.Enum, ", "),
-		Example:     schema.Example,
+		Enum:        strings.Join(values, ", "),
+		EnumValues:  values,
+		Example:     schema.example(),
+		Constraints: schema.constraints(),
 	})
 	switch schema.Type {
 	case Array:
-		nameSet := append(names, "[]")
-		out, ok := ConvertSchemaToRow(set, schema.Items, nameSet, false)
-		if !ok {
-			return nil, false
+		for i, s := range schema.PrefixItems {
+			nameSet := appendName(names, "["+strconv.Itoa(i)+"]")
+			out, ok := ConvertSchemaToRow(set, s, nameSet, false)
+			if !ok {
+				return nil, false
+			}
+			rows = append(rows, out...)
+		}
+		if schema.Items != nil && !schema.Items.isFalse {
+			nameSet := appendName(names, "[]")
+			out, ok := ConvertSchemaToRow(set, schema.Items, nameSet, false)
+			if !ok {
+				return nil, false
+			}
+			rows = append(rows, out...)
 		}
-		rows = append(rows, out...)
 	case Object:
-		for na, s := range schema.Properties {
-			isRequired := false
-			for _, rn := range schema.Required {
-				if rn == na {
-					isRequired = true
-					break
+		for _, na := range sortedPropertyNames(schema.Properties) {
+			nameSet := appendName(names, na)
+			out, ok := ConvertSchemaToRow(set, schema.Properties[na], nameSet, schema.isRequired(na))
+			if !ok {
+				return nil, false
+			}
+			rows = append(rows, out...)
+		}
+		if schema.hasAdditionalProperties() {
+			out, ok := ConvertSchemaToRow(set, schema.AdditionalProperties, appendName(names, MapKey), false)
+			if !ok {
+				return nil, false
+			}
+			rows = append(rows, out...)
+		}
+	}
+
+	if len(schema.AllOf) > 0 {
+		// The rows of allOf are merged, the first row with the same name wins.
+		index := make(map[string]int, len(rows))
+		for i, v := range rows {
+			index[v.Name] = i
+		}
+		for _, s := range schema.AllOf {
+			out, ok := ConvertSchemaToRow(set, s, names, required)
+			if !ok {
+				return nil, false
+			}
+			for _, v := range out {
+				i, ok := index[v.Name]
+				if !ok {
+					index[v.Name] = len(rows)
+					rows = append(rows, v)
+					continue
+				}
+				if rows[i].Type == "" {
+					rows[i].Type = v.Type
+				}
+				if rows[i].Description == "" {
+					rows[i].Description = v.Description
 				}
+				if rows[i].Constraints == "" {
+					rows[i].Constraints = v.Constraints
+				}
+			}
+		}
+		// The required properties may be declared by allOf members.
+		for _, rn := range schema.Required {
+			if i, ok := index[strings.Join(appendName(names, rn), ".")]; ok {
+				rows[i].Required = true
 			}
-			nameSet := append(names, na)
-			out, ok := ConvertSchemaToRow(set, s, nameSet, isRequired)
+		}
+	}
+
+	for _, variants := range []struct {
+		keyword string
+		schemas []*Schema
+	}{
+		{keyword: "oneOf", schemas: schema.OneOf},
+		{keyword: "anyOf", schemas: schema.AnyOf},
+	} {
+		for i, s := range variants.schemas {
+			nameSet := appendName(names, "("+variantLabel(variants.keyword, i, s)+")")
+			out, ok := ConvertSchemaToRow(set, s, nameSet, false)
 			if !ok {
 				return nil, false
 			}
+			for j := range out {
+				if out[j].Variant == "" {
+					out[j].Variant = variantLabel(variants.keyword, i, s)
+				}
+			}
 			rows = append(rows, out...)
 		}
 	}
 	return rows, true
 }
+
+// appendName returns a new name set, so that the name sets of siblings never share memory.
+func appendName(names []string, name string) []string {
+	out := make([]string, len(names), len(names)+1)
+	copy(out, names)
+	return append(out, name)
+}
+
+func sortedPropertyNames(properties map[string]*Schema) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// variantLabel returns the label of an alternative of oneOf/anyOf,
+// the name of the referenced definition is used if possible.
+func variantLabel(keyword string, i int, s *Schema) string {
+	if s.Ref != "" {
+		return keyword + " " + s.Ref[strings.LastIndex(s.Ref, "/")+1:]
+	}
+	if s.Title != "" {
+		return keyword + " " + s.Title
+	}
+	return keyword + " " + strconv.Itoa(i+1)
+}
+
+// MapKey is the row name segment standing for the keys of a map, e.g. `labels.{key}.value`.
+const MapKey = "{key}"
+
+// hasAdditionalProperties reports whether the schema allows the properties not declared,
+// which are the entries of a map.
+func (s *Schema) hasAdditionalProperties() bool {
+	return s.AdditionalProperties != nil && !s.AdditionalProperties.isFalse
+}
+
+func (s *Schema) isRequired(name string) bool {
+	for _, rn := range s.Required {
+		if rn == name {
+			return true
+		}
+	}
+	return false
+}
+
+// example returns the example of the schema,
+// the first of the OpenAPI 3.1 examples is used if no single example is set.
+func (s *Schema) example() string {
+	if s.Example == "" && len(s.Examples) > 0 {
+		return valueToString(s.Examples[0])
+	}
+	return s.Example
+}
+
+// constraints returns the displayed validation keywords of the schema.
+func (s *Schema) constraints() string {
+	var out []string
+	if s.Minimum != nil {
+		op := ">="
+		if s.ExclusiveMinimum {
+			op = ">"
+		}
+		out = append(out, op+" "+formatNumber(*s.Minimum))
+	}
+	if s.Maximum != nil {
+		op := "<="
+		if s.ExclusiveMaximum {
+			op = "<"
+		}
+		out = append(out, op+" "+formatNumber(*s.Maximum))
+	}
+	if s.MultipleOf != nil {
+		out = append(out, "multiple of "+formatNumber(*s.MultipleOf))
+	}
+	if v := formatRange("length", s.MinLength, s.MaxLength); v != "" {
+		out = append(out, v)
+	}
+	if s.Pattern != "" {
+		out = append(out, "pattern "+s.Pattern)
+	}
+	if v := formatRange("items", s.MinItems, s.MaxItems); v != "" {
+		out = append(out, v)
+	}
+	if s.UniqueItems {
+		out = append(out, "unique items")
+	}
+	if s.ReadOnly {
+		out = append(out, "read only")
+	}
+	if s.Default != nil {
+		out = append(out, "default "+valueToString(s.Default))
+	}
+	return strings.Join(out, ", ")
+}
+
+// formatRange formats the bounds of the length or items, e.g. "length 1..20" or "items >= 1".
+func formatRange(name string, min, max *int) string {
+	switch {
+	case min != nil && max != nil:
+		return fmt.Sprintf("%s %d..%d", name, *min, *max)
+	case min != nil:
+		return fmt.Sprintf("%s >= %d", name, *min)
+	case max != nil:
+		return fmt.Sprintf("%s <= %d", name, *max)
+	}
+	return ""
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// typeName returns the displayed type of the schema,
+// multiple types are joined with `|`.
+func (s *Schema) typeName() ParameterType {
+	types := s.Types
+	if len(types) == 0 && s.Type != "" {
+		types = []ParameterType{s.Type}
+	}
+	if len(types) < 2 && !s.Nullable {
+		return s.Type
+	}
+
+	names := make([]string, 0, len(types)+1)
+	for _, t := range types {
+		names = append(names, t.String())
+	}
+	if s.Nullable {
+		names = append(names, Null.String())
+	}
+	return ParameterType(strings.Join(names, " | "))
+}
+
+// Refs returns all the references used by the schema and its nested schemas.
+func (s *Schema) Refs() []string {
+	var out []string
+	if s.Ref != "" {
+		out = append(out, s.Ref)
+	}
+	for _, v := range s.subSchemas() {
+		out = append(out, v.Refs()...)
+	}
+	return out
+}
+
+// subSchemas returns the schemas nested directly in the schema, except $defs,
+// the properties are ordered by name so that the references are reported in a stable order.
+func (s *Schema) subSchemas() []*Schema {
+	var out []*Schema
+	if s.Items != nil {
+		out = append(out, s.Items)
+	}
+	out = append(out, s.PrefixItems...)
+	for _, name := range sortedPropertyNames(s.Properties) {
+		out = append(out, s.Properties[name])
+	}
+	if s.AdditionalProperties != nil {
+		out = append(out, s.AdditionalProperties)
+	}
+	out = append(out, s.AllOf...)
+	out = append(out, s.OneOf...)
+	out = append(out, s.AnyOf...)
+	if s.Not != nil {
+		out = append(out, s.Not)
+	}
+	return out
+}
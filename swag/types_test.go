@@ -0,0 +1,249 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swag
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRecursiveDefinitionRows(t *testing.T) {
+	definitions := map[string]*Schema{
+		"Tree": {Type: Object, Properties: map[string]*Schema{
+			"value":    {Type: String},
+			"children": {Type: Array, Items: &Schema{Ref: "#/definitions/Tree"}},
+		}},
+		// A and B are aliases of each other, C refers to the cycle.
+		"A": {Ref: "#/definitions/B"},
+		"B": {Ref: "#/definitions/A"},
+		"C": {Type: Object, Properties: map[string]*Schema{"a": {Ref: "#/definitions/A"}}},
+	}
+	set, err := ConvertSchemaToRowSet(definitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type row struct {
+		Name        string
+		Type        ParameterType
+		Description string
+		Recursive   bool
+	}
+	rowsOf := func(ref string) []row {
+		var rows []row
+		for _, r := range set[ref] {
+			rows = append(rows, row{Name: r.Name, Type: r.Type, Description: r.Description, Recursive: r.Recursive})
+		}
+		return rows
+	}
+
+	tree := []row{
+		{Type: Object},
+		{Name: "children", Type: Array},
+		{Name: "children.[]", Type: Object, Description: RecursiveMarker("Tree"), Recursive: true},
+		{Name: "value", Type: String},
+	}
+	if got := rowsOf("#/definitions/Tree"); !reflect.DeepEqual(got, tree) {
+		t.Errorf("Tree rows = %+v, want %+v", got, tree)
+	}
+
+	// the alias cycle is cut where it comes back to A, and the marker names the last alias
+	alias := []row{{Description: RecursiveMarker("B"), Recursive: true}}
+	for _, ref := range []string{"#/definitions/A", "#/definitions/B"} {
+		if got := rowsOf(ref); !reflect.DeepEqual(got, alias) {
+			t.Errorf("%s rows = %+v, want %+v", ref, got, alias)
+		}
+	}
+	c := []row{
+		{Type: Object},
+		{Name: "a", Description: RecursiveMarker("B"), Recursive: true},
+	}
+	if got := rowsOf("#/definitions/C"); !reflect.DeepEqual(got, c) {
+		t.Errorf("C rows = %+v, want %+v", got, c)
+	}
+}
+
+func TestRecursiveDefinitionExamples(t *testing.T) {
+	definitions := map[string]*Schema{
+		"Tree": {Type: Object, Properties: map[string]*Schema{
+			"value":    {Type: String, Example: "root"},
+			"children": {Type: Array, Items: &Schema{Ref: "#/definitions/Tree"}},
+		}},
+		"A": {Ref: "#/definitions/B"},
+		"B": {Ref: "#/definitions/A"},
+	}
+	set, err := ConvertSchemaToMap(definitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the recursive items are left out instead of nesting the tree forever
+	want := map[string]interface{}{"value": "root", "children": []interface{}{}}
+	if tree := set["#/definitions/Tree"]; !reflect.DeepEqual(tree, want) {
+		t.Errorf("Tree example = %#v, want %#v", tree, want)
+	}
+	for _, ref := range []string{"#/definitions/A", "#/definitions/B"} {
+		if v := set[ref]; v != nil {
+			t.Errorf("%s example = %#v, want nothing for the alias cycle", ref, v)
+		}
+	}
+}
+
+func TestUndefinedDefinitions(t *testing.T) {
+	definitions := map[string]*Schema{
+		"Pet": {Type: Object, Properties: map[string]*Schema{
+			"owner": {Ref: "#/definitions/Owner"},
+			"tags":  {Type: Array, Items: &Schema{Ref: "#/definitions/Tag"}},
+		}},
+	}
+	var converted []string
+	err := resolveDefinitions(definitions, func(ref, name string, s *Schema) {}, func(ref string, s *Schema) {
+		converted = append(converted, ref)
+	})
+	want := "undefined $ref: #/definitions/Owner (in #/definitions/Pet), #/definitions/Tag (in #/definitions/Pet)"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %s", err, want)
+	}
+	// the definitions are still converted, so that the error lists all the undefined references
+	if !reflect.DeepEqual(converted, []string{"#/definitions/Pet"}) {
+		t.Errorf("converted = %v, want Pet", converted)
+	}
+
+	if _, err := ConvertSchemaToRowSet(definitions); err == nil {
+		t.Error("rows of the undefined references are converted without an error")
+	}
+}
+
+// mapDefinitions returns the maps declared with additionalProperties as a schema, a reference, true and false.
+func mapDefinitions(t *testing.T) map[string]*Schema {
+	t.Helper()
+	var definitions map[string]*Schema
+	err := json.Unmarshal([]byte(`{
+		"Labels": {"type": "object", "properties": {"name": {"type": "string", "example": "env"}},
+			"additionalProperties": {"type": "object", "properties": {"value": {"type": "string", "example": "prod"}}}},
+		"Tags": {"type": "object", "additionalProperties": {"$ref": "#/definitions/Tag"}},
+		"Tag": {"type": "string", "example": "dog"},
+		"Meta": {"type": "object", "additionalProperties": true},
+		"Closed": {"type": "object", "properties": {"id": {"type": "integer", "example": 1}}, "additionalProperties": false},
+		"Keyed": {"type": "object", "properties": {"key": {"type": "string", "example": "declared"}},
+			"additionalProperties": {"type": "string", "example": "entry"}}
+	}`), &definitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return definitions
+}
+
+func TestMapRows(t *testing.T) {
+	set, err := ConvertSchemaToRowSet(mapDefinitions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type row struct {
+		Name string
+		Type ParameterType
+	}
+	for name, want := range map[string][]row{
+		"Labels": {{Type: Object}, {Name: "name", Type: String}, {Name: MapKey, Type: Object}, {Name: MapKey + ".value", Type: String}},
+		"Tags":   {{Type: Object}, {Name: MapKey, Type: String}},
+		"Meta":   {{Type: Object}, {Name: MapKey}},
+		"Closed": {{Type: Object}, {Name: "id", Type: Integer}},
+	} {
+		var got []row
+		for _, r := range set[DefinitionRef(name)] {
+			got = append(got, row{Name: r.Name, Type: r.Type})
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s rows = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestCompositionRows(t *testing.T) {
+	definitions := map[string]*Schema{
+		"Base": {Type: Object, Required: []string{"id"}, Properties: map[string]*Schema{
+			"id": {Type: Integer, Description: "the id"},
+		}},
+		// the own rows go first, the allOf members add the missing rows and fill the missing columns
+		"Pet": {
+			Type:       Object,
+			Required:   []string{"name", "tag"},
+			Properties: map[string]*Schema{"name": {Type: String}},
+			AllOf: []*Schema{
+				{Ref: "#/definitions/Base"},
+				{Type: Object, Properties: map[string]*Schema{
+					"name": {Type: Integer, Description: "the name"},
+					"tag":  {Type: String},
+				}},
+			},
+		},
+		"Circle": {Type: Object, Properties: map[string]*Schema{"radius": {Type: Number}}},
+		"Shape": {
+			Type:       Object,
+			Properties: map[string]*Schema{"kind": {Type: String}},
+			OneOf: []*Schema{
+				{Ref: "#/definitions/Circle"},
+				{Title: "Square", Type: Object, Properties: map[string]*Schema{"side": {Type: Number}}},
+			},
+			AnyOf: []*Schema{
+				{Type: Object, Properties: map[string]*Schema{"color": {Type: String}}},
+			},
+		},
+	}
+	set, err := ConvertSchemaToRowSet(definitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type row struct {
+		Name        string
+		Type        ParameterType
+		Required    bool
+		Description string
+		Variant     string
+	}
+	rowsOf := func(name string) []row {
+		var rows []row
+		for _, r := range set[DefinitionRef(name)] {
+			rows = append(rows, row{Name: r.Name, Type: r.Type, Required: r.Required, Description: r.Description, Variant: r.Variant})
+		}
+		return rows
+	}
+
+	pet := []row{
+		{Type: Object},
+		{Name: "name", Type: String, Required: true, Description: "the name"},
+		{Name: "id", Type: Integer, Required: true, Description: "the id"},
+		{Name: "tag", Type: String, Required: true},
+	}
+	if got := rowsOf("Pet"); !reflect.DeepEqual(got, pet) {
+		t.Errorf("Pet rows = %+v, want %+v", got, pet)
+	}
+
+	// each alternative is labelled by the definition it refers to, its title or its position
+	shape := []row{
+		{Type: Object},
+		{Name: "kind", Type: String},
+		{Name: "(oneOf Circle)", Type: Object, Variant: "oneOf Circle"},
+		{Name: "(oneOf Circle).radius", Type: Number, Variant: "oneOf Circle"},
+		{Name: "(oneOf Square)", Type: Object, Variant: "oneOf Square"},
+		{Name: "(oneOf Square).side", Type: Number, Variant: "oneOf Square"},
+		{Name: "(anyOf 1)", Type: Object, Variant: "anyOf 1"},
+		{Name: "(anyOf 1).color", Type: String, Variant: "anyOf 1"},
+	}
+	if got := rowsOf("Shape"); !reflect.DeepEqual(got, shape) {
+		t.Errorf("Shape rows = %+v, want %+v", got, shape)
+	}
+}
@@ -0,0 +1,612 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/zc2638/apidoc/swag"
+)
+
+// Problem is a violation of the spec rules found by Validate.
+type Problem struct {
+	// Pointer is the json pointer of the invalid value,
+	// or of the object missing the required field.
+	Pointer string `json:"pointer"`
+
+	// Line is the line of the pointer in the source, 0 if it is unknown.
+	Line int `json:"line"`
+
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	pointer := p.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	if p.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", p.Line, pointer, p.Message)
+	}
+	return pointer + ": " + p.Message
+}
+
+var (
+	operationMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+	swaggerParameterLocations = []string{"query", "header", "path", "formData", "body"}
+	openAPIParameterLocations = []string{"query", "header", "path", "cookie"}
+
+	pathTemplateRegexp = regexp.MustCompile(`{([^{}]+)}`)
+)
+
+// Validate checks the json or yaml content against the Swagger 2.0 rules,
+// OpenAPI 3.x definitions are checked against the equivalent rules.
+// The problems are sorted by their source lines, an error is returned only if the content can not be decoded.
+func Validate(content []byte) ([]Problem, error) {
+	doc, err := decodeDocument(content)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &validator{doc: doc, lines: sourceLines(content), operationIDs: make(map[string]string)}
+	v.validate()
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		return v.problems[i].Line < v.problems[j].Line
+	})
+	return v.problems, nil
+}
+
+// ValidateSource reads the src like LoadSource and validates it like Validate,
+// the references to other documents which fail to resolve are reported at the lines of their $ref.
+func ValidateSource(src string, opt LoadOption) ([]Problem, error) {
+	content, err := ReadSource(src)
+	if err != nil {
+		return nil, err
+	}
+	problems, err := Validate(content)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := unresolvedRefs(content, src, opt)
+	if err != nil || len(refs) == 0 {
+		return problems, err
+	}
+
+	lines := sourceLines(content)
+	for _, ref := range refs {
+		problems = append(problems, Problem{
+			Pointer: ref.pointer,
+			Line:    lineOf(lines, ref.pointer),
+			Message: ref.err.Error(),
+		})
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+type validator struct {
+	doc      interface{}
+	lines    map[string]int
+	openAPI  bool
+	problems []Problem
+
+	// the pointers of the operations by operationId
+	operationIDs map[string]string
+}
+
+func (v *validator) report(pointer, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		Pointer: pointer,
+		Line:    v.line(pointer),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) line(pointer string) int {
+	return lineOf(v.lines, pointer)
+}
+
+func (v *validator) validate() {
+	root, ok := v.doc.(map[string]interface{})
+	if !ok {
+		v.report("", "document must be an object")
+		return
+	}
+
+	if version, ok := root["openapi"]; ok {
+		v.openAPI = true
+		if s, _ := stringValue(version); !strings.HasPrefix(s, "3.") {
+			v.report("/openapi", "unsupported openapi version %v, expect 3.x", version)
+		}
+	} else if version, ok := root["swagger"]; !ok {
+		v.report("/swagger", "swagger version is required")
+	} else if s, _ := stringValue(version); s != "2.0" {
+		v.report("/swagger", "unsupported swagger version %v, expect \"2.0\"", version)
+	}
+
+	if info, ok := v.object(root, "", "info", true); ok {
+		v.requiredString(info, "/info", "title")
+		v.requiredString(info, "/info", "version")
+	}
+
+	if paths, ok := v.object(root, "", "paths", true); ok {
+		for _, p := range sortedKeys(paths) {
+			pointer := swag.JSONPointer("paths", p)
+			if !strings.HasPrefix(p, "/") {
+				v.report(pointer, "path %s must begin with a slash", p)
+			}
+			item, ok := paths[p].(map[string]interface{})
+			if !ok {
+				v.report(pointer, "path item must be an object")
+				continue
+			}
+			v.validatePath(p, pointer, item)
+		}
+	}
+
+	v.validateTags(root)
+	v.validateRefs(v.doc, "")
+}
+
+func (v *validator) validatePath(path, pointer string, item map[string]interface{}) {
+	common := v.parameters(item, pointer)
+	for _, p := range common {
+		if p.in == "path" && !strings.Contains(path, "{"+p.name+"}") {
+			v.report(p.pointer, "path parameter %s is not in the path %s", p.name, path)
+		}
+	}
+	for _, method := range operationMethods {
+		if method == "trace" && !v.openAPI {
+			continue
+		}
+		raw, ok := item[method]
+		if !ok {
+			continue
+		}
+		opPointer := pointer + "/" + method
+		op, ok := raw.(map[string]interface{})
+		if !ok {
+			v.report(opPointer, "operation must be an object")
+			continue
+		}
+		v.validateOperation(path, opPointer, op, common)
+	}
+}
+
+func (v *validator) validateOperation(path, pointer string, op map[string]interface{}, common []parameterRef) {
+	if raw, ok := op["operationId"]; ok {
+		id, _ := stringValue(raw)
+		if other, ok := v.operationIDs[id]; ok {
+			v.report(pointer+"/operationId", "duplicate operationId %s, it is also used by %s", id, other)
+		} else {
+			v.operationIDs[id] = pointer
+		}
+	}
+
+	// the operation parameters override the path parameters with the same name and location
+	params := make(map[string]parameterRef)
+	var order []string
+	add := func(p parameterRef) {
+		key := p.in + ":" + p.name
+		if _, ok := params[key]; !ok {
+			order = append(order, key)
+		}
+		params[key] = p
+	}
+	for _, p := range common {
+		add(p)
+	}
+	seen := make(map[string]struct{})
+	var body, form bool
+	for _, p := range v.parameters(op, pointer) {
+		key := p.in + ":" + p.name
+		if _, ok := seen[key]; ok {
+			v.report(p.pointer, "duplicate parameter %s in %s", p.name, p.in)
+		}
+		seen[key] = struct{}{}
+		switch p.in {
+		case "body":
+			if body {
+				v.report(p.pointer, "operation can only have one body parameter")
+			}
+			body = true
+		case "formData":
+			form = true
+		}
+		add(p)
+	}
+	if body && form {
+		v.report(pointer+"/parameters", "body and formData parameters can not be used together")
+	}
+
+	declared := make(map[string]struct{})
+	for _, key := range order {
+		p := params[key]
+		if p.in != "path" {
+			continue
+		}
+		declared[p.name] = struct{}{}
+		// the path item parameters are checked once by validatePath
+		if strings.HasPrefix(p.pointer, pointer+"/") && !strings.Contains(path, "{"+p.name+"}") {
+			v.report(p.pointer, "path parameter %s is not in the path %s", p.name, path)
+		}
+	}
+	for _, match := range pathTemplateRegexp.FindAllStringSubmatch(path, -1) {
+		if _, ok := declared[match[1]]; !ok {
+			v.report(pointer, "path parameter %s is not declared", match[1])
+		}
+	}
+
+	responses, ok := v.object(op, pointer, "responses", true)
+	if ok && len(responses) == 0 {
+		v.report(pointer+"/responses", "responses must not be empty")
+	}
+}
+
+type parameterRef struct {
+	pointer string
+	name    string
+	in      string
+}
+
+// parameters checks the parameters of the path item or operation,
+// the referenced parameters are resolved and the invalid ones are skipped.
+func (v *validator) parameters(obj map[string]interface{}, pointer string) []parameterRef {
+	raw, ok := obj["parameters"]
+	if !ok {
+		return nil
+	}
+	pointer += "/parameters"
+	list, ok := raw.([]interface{})
+	if !ok {
+		v.report(pointer, "parameters must be an array")
+		return nil
+	}
+
+	locations := swaggerParameterLocations
+	if v.openAPI {
+		locations = openAPIParameterLocations
+	}
+	var out []parameterRef
+	for i, item := range list {
+		itemPointer := pointer + "/" + strconv.Itoa(i)
+		param, ok := item.(map[string]interface{})
+		if !ok {
+			v.report(itemPointer, "parameter must be an object")
+			continue
+		}
+		if ref, ok := param["$ref"].(string); ok {
+			// unresolved references are reported by validateRefs
+			target, ok := v.resolve(ref)
+			if !ok {
+				continue
+			}
+			if param, ok = target.(map[string]interface{}); !ok {
+				continue
+			}
+		}
+
+		name, _ := stringValue(param["name"])
+		if name == "" {
+			v.report(itemPointer, "parameter name is required")
+			continue
+		}
+		in, _ := stringValue(param["in"])
+		if in == "" {
+			v.report(itemPointer, "parameter %s location (in) is required", name)
+			continue
+		}
+		if !contains(locations, in) {
+			v.report(itemPointer+"/in", "unknown parameter location %s, expect one of %s", in, strings.Join(locations, ", "))
+			continue
+		}
+		if in == "path" {
+			if required, _ := coerceScalars(param["required"], reflect.TypeOf(false)).(bool); !required {
+				v.report(itemPointer, "path parameter %s must be required", name)
+			}
+		}
+		if !v.openAPI {
+			if in == "body" {
+				if _, ok := param["schema"]; !ok {
+					v.report(itemPointer, "body parameter %s requires a schema", name)
+				}
+			} else if _, ok := param["type"]; !ok {
+				v.report(itemPointer, "parameter %s requires a type", name)
+			}
+		}
+		out = append(out, parameterRef{pointer: itemPointer, name: name, in: in})
+	}
+	return out
+}
+
+func (v *validator) validateTags(root map[string]interface{}) {
+	tags, ok := root["tags"].([]interface{})
+	if !ok {
+		return
+	}
+	seen := make(map[string]struct{})
+	for i, item := range tags {
+		pointer := "/tags/" + strconv.Itoa(i)
+		tag, ok := item.(map[string]interface{})
+		if !ok {
+			v.report(pointer, "tag must be an object")
+			continue
+		}
+		name, _ := stringValue(tag["name"])
+		if name == "" {
+			v.report(pointer, "tag name is required")
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			v.report(pointer+"/name", "duplicate tag %s", name)
+		}
+		seen[name] = struct{}{}
+	}
+}
+
+// validateRefs checks that the local references point to existing values,
+// the references to other documents are resolved by ValidateSource.
+func (v *validator) validateRefs(value interface{}, pointer string) {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(val) {
+			child := pointer + swag.JSONPointer(key)
+			ref, ok := val[key].(string)
+			if key != "$ref" || !ok {
+				v.validateRefs(val[key], child)
+				continue
+			}
+			if !strings.HasPrefix(ref, "#") {
+				continue
+			}
+			if _, ok := v.resolve(ref); !ok && !v.resolveDefs(ref, pointer) {
+				v.report(child, "reference %s is not found", ref)
+			}
+		}
+	case []interface{}:
+		for i, item := range val {
+			v.validateRefs(item, pointer+"/"+strconv.Itoa(i))
+		}
+	}
+}
+
+// resolve returns the value of the local reference.
+func (v *validator) resolve(ref string) (interface{}, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return v.doc, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+	current := v.doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = unescapePointer(token)
+		switch val := current.(type) {
+		case map[string]interface{}:
+			next, ok := val[token]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(val) {
+				return nil, false
+			}
+			current = val[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// resolveDefs reports whether the `#/$defs/...` reference of the OpenAPI 3.1 schema at the pointer
+// is found in the $defs of the schema or one of its enclosing schemas.
+func (v *validator) resolveDefs(ref, pointer string) bool {
+	if !v.openAPI || !strings.HasPrefix(ref, "#/$defs/") {
+		return false
+	}
+	for {
+		if _, ok := v.resolve("#" + pointer + ref[1:]); ok {
+			return true
+		}
+		i := strings.LastIndex(pointer, "/")
+		if i < 0 {
+			return false
+		}
+		pointer = pointer[:i]
+	}
+}
+
+// object returns the object field of the obj, the missing field is reported if it is required.
+func (v *validator) object(obj map[string]interface{}, pointer, key string, required bool) (map[string]interface{}, bool) {
+	raw, ok := obj[key]
+	if !ok {
+		if required {
+			v.report(pointer+"/"+key, "%s is required", key)
+		}
+		return nil, false
+	}
+	val, ok := raw.(map[string]interface{})
+	if !ok {
+		v.report(pointer+"/"+key, "%s must be an object", key)
+		return nil, false
+	}
+	return val, true
+}
+
+func (v *validator) requiredString(obj map[string]interface{}, pointer, key string) {
+	name := strings.ReplaceAll(strings.TrimPrefix(pointer+"/"+key, "/"), "/", ".")
+	raw, ok := obj[key]
+	if !ok {
+		v.report(pointer+"/"+key, "%s is required", name)
+		return
+	}
+	if s, ok := stringValue(raw); !ok || strings.TrimSpace(s) == "" {
+		v.report(pointer+"/"+key, "%s must be a non-empty string", name)
+	}
+}
+
+// sourceLines returns the lines of the values in the json or yaml content by their json pointers.
+func sourceLines(content []byte) map[string]int {
+	if json.Valid(content) {
+		return jsonLines(content)
+	}
+	return yamlLines(content)
+}
+
+// lineOf returns the line of the pointer, or of its closest ancestor in the source.
+func lineOf(lines map[string]int, pointer string) int {
+	for {
+		if line, ok := lines[pointer]; ok {
+			return line
+		}
+		i := strings.LastIndex(pointer, "/")
+		if i < 0 {
+			return 0
+		}
+		pointer = pointer[:i]
+	}
+}
+
+// jsonLines returns the lines of the values in the json content by their pointers.
+func jsonLines(content []byte) map[string]int {
+	lines := make(map[string]int)
+	dec := json.NewDecoder(bytes.NewReader(content))
+	lineAt := func(offset int64) int {
+		i := int(offset)
+		// skip the separators before the value
+		for i < len(content) && strings.IndexByte(" \t\r\n:,", content[i]) >= 0 {
+			i++
+		}
+		return bytes.Count(content[:i], []byte("\n")) + 1
+	}
+
+	var walk func(pointer string) error
+	walk = func(pointer string) error {
+		lines[pointer] = lineAt(dec.InputOffset())
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		delim, ok := tok.(json.Delim)
+		if !ok {
+			return nil
+		}
+		switch delim {
+		case '{':
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := walk(pointer + swag.JSONPointer(fmt.Sprint(key))); err != nil {
+					return err
+				}
+			}
+		case '[':
+			for i := 0; dec.More(); i++ {
+				if err := walk(pointer + "/" + strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+		}
+		// the closing delimiter
+		_, err = dec.Token()
+		return err
+	}
+	// the content is valid json, the lines found before any error are kept
+	_ = walk("")
+	return lines
+}
+
+// yamlLines returns the lines of the values in the yaml content by their pointers,
+// the line of a mapping value is the line of its key.
+func yamlLines(content []byte) map[string]int {
+	lines := make(map[string]int)
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &root); err != nil {
+		return lines
+	}
+
+	var walk func(node *yamlv3.Node, pointer string, line int)
+	walk = func(node *yamlv3.Node, pointer string, line int) {
+		lines[pointer] = line
+		switch node.Kind {
+		case yamlv3.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, pointer, child.Line)
+			}
+		case yamlv3.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				walk(value, pointer+swag.JSONPointer(key.Value), key.Line)
+			}
+		case yamlv3.SequenceNode:
+			for i, child := range node.Content {
+				walk(child, pointer+"/"+strconv.Itoa(i), child.Line)
+			}
+		}
+	}
+	walk(&root, "", 1)
+	return lines
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+func unescapePointer(token string) string {
+	return pointerUnescaper.Replace(token)
+}
+
+// stringValue returns the scalar as a string, the numbers and booleans are accepted as the loader does.
+func stringValue(v interface{}) (string, bool) {
+	s, ok := coerceScalars(v, reflect.TypeOf("")).(string)
+	return s, ok
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+// Copyright © 2022 zc2638 <zc2638@qq.com>.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apidoc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidateTestdata(t *testing.T) {
+	// the fixtures of the bundle tests are in the subdirectories
+	files, err := filepath.Glob("testdata/*.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		problems, err := Validate(content)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if len(problems) > 0 {
+			t.Errorf("%s: unexpected problems %v", file, problems)
+		}
+	}
+}
+
+// location is where a problem is reported, the messages are left to the readers.
+type location struct {
+	Pointer string
+	Line    int
+}
+
+func problemLocations(t *testing.T, problems []Problem) []location {
+	var locations []location
+	for _, p := range problems {
+		if p.Message == "" {
+			t.Errorf("problem at %s has no message", p.Pointer)
+		}
+		locations = append(locations, location{Pointer: p.Pointer, Line: p.Line})
+	}
+	return locations
+}
+
+func TestValidateLocations(t *testing.T) {
+	cases := map[string]struct {
+		content string
+		want    []location
+	}{
+		"swagger version, info and paths": {
+			content: `swagger: "1.2"
+info:
+  version: "1"
+paths:
+  pets:
+    get:
+      responses: {}
+`,
+			want: []location{
+				{Pointer: "/swagger", Line: 1},
+				{Pointer: "/info/title", Line: 2},
+				{Pointer: "/paths/pets", Line: 5},
+				{Pointer: "/paths/pets/get/responses", Line: 7},
+			},
+		},
+		"swagger parameters, operationIds and refs": {
+			content: `swagger: "2.0"
+info: {title: t, version: "1"}
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: limit
+          in: cookie
+          type: integer
+      responses:
+        "200": {description: ok}
+    put:
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          type: string
+        - name: body
+          in: body
+          schema: {$ref: "#/definitions/Missing"}
+      responses:
+        "200": {description: ok}
+`,
+			want: []location{
+				{Pointer: "/paths/~1pets~1{id}/get", Line: 5},
+				{Pointer: "/paths/~1pets~1{id}/get/parameters/0/in", Line: 9},
+				{Pointer: "/paths/~1pets~1{id}/put/operationId", Line: 14},
+				{Pointer: "/paths/~1pets~1{id}/put/parameters/0", Line: 16},
+				{Pointer: "/paths/~1pets~1{id}/put/parameters/1/schema/$ref", Line: 21},
+			},
+		},
+		"openapi json tags and parameters": {
+			content: `{
+  "openapi": "3.0.3",
+  "info": {"title": "t", "version": "1"},
+  "tags": [{"name": "pet"}, {"name": "pet"}],
+  "paths": {
+    "/pets": {"get": {"parameters": [{"name": "q", "in": "body"}], "responses": {"200": {"description": "ok"}}}}
+  }
+}`,
+			want: []location{
+				{Pointer: "/tags/1/name", Line: 4},
+				{Pointer: "/paths/~1pets/get/parameters/0/in", Line: 6},
+			},
+		},
+		"not an object": {
+			content: `- swagger`,
+			want:    []location{{Pointer: "", Line: 1}},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			problems, err := Validate([]byte(c.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := problemLocations(t, problems); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("locations = %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestValidateUndecodable(t *testing.T) {
+	for _, content := range []string{"swagger: [", "info: a: b"} {
+		if problems, err := Validate([]byte(content)); err == nil {
+			t.Errorf("%q: problems %v without an error", content, problems)
+		}
+	}
+}
+
+func TestValidateSourceRefs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"swagger.yaml": `swagger: "2.0"
+info: {title: pets, version: "1"}
+paths: {}
+definitions:
+  Pet:
+    $ref: models.yaml#/Pet
+  Missing:
+    $ref: models.yaml#/Missing
+  Owner:
+    $ref: models.yaml#/Owner
+  Remote:
+    $ref: https://example.com/models.yaml#/Pet
+  Local:
+    $ref: "#/definitions/Nothing"
+`,
+		"models.yaml": `Pet:
+  type: object
+Owner:
+  properties:
+    address:
+      $ref: missing.yaml#/Address
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	problems, err := ValidateSource(filepath.Join(dir, "swagger.yaml"), LoadOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the failure inside models.yaml is reported at the $ref of the root document leading to it
+	want := []location{
+		{Pointer: "/definitions/Missing/$ref", Line: 8},
+		{Pointer: "/definitions/Owner/$ref", Line: 10},
+		{Pointer: "/definitions/Remote/$ref", Line: 12},
+		{Pointer: "/definitions/Local/$ref", Line: 14},
+	}
+	if got := problemLocations(t, problems); !reflect.DeepEqual(got, want) {
+		t.Errorf("locations = %+v, want %+v", got, want)
+	}
+}